@@ -0,0 +1,89 @@
+// 本ファイルは tier2 共通 auth middleware の認可拒否 audit hook。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-001 / 002
+//
+// 役割:
+//   認証失敗（401）と認可拒否（403）を、各サービスが独自の wrapper middleware を書かずに
+//   audit 基盤（tier1 Audit API 等）へ転送できるようにする。
+//     - Required は 401 の時点で Config.AuditHook を呼ぶ
+//     - 認証成功時は hook を context に載せ、RequireAnyRole / RequirePermission 等の guard が 403 で呼ぶ
+//   hook は要求経路で同期的に呼ばれるため、外部送信は hook 側で非同期化すること。
+
+package auth
+
+// 標準 import。
+import (
+	// context 伝搬。
+	"context"
+	// HTTP server。
+	"net/http"
+)
+
+// auditHookKey は認証済 context に載せる AuditHook のキー（package 外からは参照させない）。
+const auditHookKey contextKey = "k1s0.audit_hook"
+
+// Denial は 401 / 403 判定 1 件の内容。401 のうち token 検証前の拒否では識別情報は空。
+type Denial struct {
+	// HTTP status（401 / 403）。
+	Status int
+	// 拒否理由（応答 body の message と同じ）。
+	Reason string
+	// HTTP method。
+	Method string
+	// ServeMux の pattern（未解決なら URL path）。
+	Route string
+	// 認証済の subject / tenant_id / iss / roles（未認証なら空）。
+	Subject  string
+	TenantID string
+	Issuer   string
+	Roles    []string
+}
+
+// AuditHook は 401 / 403 判定の通知先。
+type AuditHook interface {
+	// OnDenied は拒否 1 件ごとに呼ばれる。
+	OnDenied(ctx context.Context, d Denial)
+}
+
+// AuditHookFunc は関数を AuditHook として使う adapter。
+type AuditHookFunc func(ctx context.Context, d Denial)
+
+// OnDenied は f を呼ぶ。
+func (f AuditHookFunc) OnDenied(ctx context.Context, d Denial) {
+	// 委譲する。
+	f(ctx, d)
+}
+
+// auditHookFromContext は Required が載せた hook を返す（未設定なら nil）。
+func auditHookFromContext(ctx context.Context) AuditHook {
+	// 型アサーション。
+	h, _ := ctx.Value(auditHookKey).(AuditHook)
+	// 値を返す。
+	return h
+}
+
+// auditDenied は hook が設定されていれば ctx の識別情報と r の経路を添えて通知する。
+func auditDenied(ctx context.Context, hook AuditHook, r *http.Request, status int, reason string) {
+	// 未設定は何もしない。
+	if hook == nil {
+		// 通知しない。
+		return
+	}
+	// pattern 未解決（mux の外側）では path を使う。
+	route := r.Pattern
+	if route == "" {
+		route = r.URL.Path
+	}
+	// 通知する。
+	hook.OnDenied(ctx, Denial{
+		Status:   status,
+		Reason:   reason,
+		Method:   r.Method,
+		Route:    route,
+		Subject:  SubjectFromContext(ctx),
+		TenantID: TenantIDFromContext(ctx),
+		Issuer:   IssuerFromContext(ctx),
+		Roles:    RolesFromContext(ctx),
+	})
+}
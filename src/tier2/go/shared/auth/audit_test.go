@@ -0,0 +1,59 @@
+// 本ファイルは認可拒否 audit hook の単体テスト。
+//
+// テスト観点:
+//   - 401 は理由と経路付きで通知され、識別情報は空
+//   - 後段 guard の 403 は subject / tenant / roles 付きで通知される
+//   - 通過した要求は通知されない
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// recordingHook は通知された Denial を記録する。
+type recordingHook struct {
+	got []Denial
+}
+
+// OnDenied は d を記録する。
+func (h *recordingHook) OnDenied(_ context.Context, d Denial) {
+	h.got = append(h.got, d)
+}
+
+func TestAuditHook_Unauthorized(t *testing.T) {
+	hook := &recordingHook{}
+	h := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: []byte("s"), AuditHook: hook})(http.HandlerFunc(passthroughHandler))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stock/reconcile", nil))
+	if len(hook.got) != 1 {
+		t.Fatalf("want 1 denial, got %d", len(hook.got))
+	}
+	d := hook.got[0]
+	if d.Status != http.StatusUnauthorized || d.Reason != "missing bearer token" || d.Method != http.MethodPost || d.Route != "/stock/reconcile" || d.Subject != "" {
+		t.Fatalf("denial = %+v", d)
+	}
+}
+
+func TestAuditHook_ForbiddenFromGuard(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	hook := &recordingHook{}
+	h := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret, AuditHook: hook})(
+		RequireAnyRole("admin")(http.HandlerFunc(passthroughHandler)))
+	for _, roles := range [][]string{{"admin"}, {"user"}} {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+authtest.HMACToken(t, secret, authtest.Claims{Subject: "u1", TenantID: "T1", Roles: roles}))
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(hook.got) != 1 {
+		t.Fatalf("only the denied request should be audited, got %d", len(hook.got))
+	}
+	d := hook.got[0]
+	if d.Status != http.StatusForbidden || d.Subject != "u1" || d.TenantID != "T1" || len(d.Roles) != 1 || d.Roles[0] != "user" {
+		t.Fatalf("denial = %+v", d)
+	}
+}
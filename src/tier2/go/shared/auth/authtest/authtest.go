@@ -0,0 +1,239 @@
+// 本ファイルは tier2 共通 auth middleware を使うサービス向けの test token 発行ヘルパ。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/30_tier2レイアウト/03_go_services配置.md
+//
+// scope:
+//   各サービスの handler test が鍵ペア生成 / JWKS 配信 / 署名付き token 発行を
+//   毎回 100 行近くコピーしないよう、shared/auth が受け付ける形の token を発行する。
+//     - NewIssuer    : 指定 alg の鍵ペアを持つ発行者（RS* / PS* / ES* / EdDSA、WithKeyID で kid 指定）
+//     - Issuer.Token : tenant_id / realm_access.roles / scope / cnf.jkt 付き token
+//     - Issuer.JWKS / ServeJWKS : Config.JWKSJSON 用の JSON / Config.JWKSURL 用の test server
+//     - HMACToken    : mode=hmac 用の HS256 token
+//   auth package の内部 test からも使うため、auth package は import しない（import cycle 回避）。
+//
+// 利用例:
+//   iss := authtest.NewIssuer(t, jose.ES256)
+//   cfg := auth.Config{Mode: auth.AuthModeJWKS, JWKSURL: iss.ServeJWKS(t)}
+//   req.Header.Set("Authorization", "Bearer "+iss.Token(t, authtest.Claims{Roles: []string{"admin"}}))
+
+// Package authtest は shared/auth 向けの署名付き test token 発行ヘルパ。
+package authtest
+
+// 標準 / 外部 import。
+import (
+	// 鍵生成。
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	// JWKS encode。
+	"encoding/json"
+	// エラー生成。
+	"fmt"
+	// JWKS 配信 server。
+	"net/http"
+	"net/http/httptest"
+	// scope クレームの連結。
+	"strings"
+	// test 失敗報告。
+	"testing"
+	// 期限。
+	"time"
+
+	// JOSE 実装。
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// 既定のクレーム値。
+const (
+	// DefaultSubject は Claims.Subject 未指定時の sub。
+	DefaultSubject = "test-user"
+	// DefaultTenantID は Claims.TenantID 未指定時の tenant_id。
+	DefaultTenantID = "test-tenant"
+	// DefaultTTL は Claims.TTL 未指定時の有効期間。
+	DefaultTTL = 5 * time.Minute
+)
+
+// Claims は発行する token の内容。ゼロ値は既定値で補う。
+type Claims struct {
+	// sub（空なら DefaultSubject）。
+	Subject string
+	// tenant_id（空なら DefaultTenantID）。
+	TenantID string
+	// iss（空なら付与しない）。
+	Issuer string
+	// realm_access.roles。
+	Roles []string
+	// scope（空白区切りで付与）。
+	Scopes []string
+	// cnf.jkt（DPoP 束縛 token 用）。
+	JKT string
+	// 有効期間（0 で DefaultTTL、負値で期限切れ token）。
+	TTL time.Duration
+	// 任意の追加クレーム（同名の標準クレームを上書きし、値 nil のクレームは省く）。
+	Extra map[string]any
+}
+
+// payload は c を JWT payload の map に変換する。
+func (c Claims) payload() map[string]any {
+	// 既定値を補う。
+	sub, tenant, ttl := c.Subject, c.TenantID, c.TTL
+	if sub == "" {
+		sub = DefaultSubject
+	}
+	if tenant == "" {
+		tenant = DefaultTenantID
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	// shared/auth が解釈するクレームを組み立てる。
+	now := time.Now()
+	p := map[string]any{
+		"sub":       sub,
+		"tenant_id": tenant,
+		"iat":       jwt.NewNumericDate(now),
+		"exp":       jwt.NewNumericDate(now.Add(ttl)),
+	}
+	if c.Issuer != "" {
+		p["iss"] = c.Issuer
+	}
+	if len(c.Roles) > 0 {
+		p["realm_access"] = map[string]any{"roles": c.Roles}
+	}
+	if len(c.Scopes) > 0 {
+		p["scope"] = strings.Join(c.Scopes, " ")
+	}
+	if c.JKT != "" {
+		p["cnf"] = map[string]any{"jkt": c.JKT}
+	}
+	// 追加クレームで上書きする。
+	for k, v := range c.Extra {
+		if v == nil {
+			delete(p, k)
+			continue
+		}
+		p[k] = v
+	}
+	// 組み立てた payload を返す。
+	return p
+}
+
+// Issuer は 1 組の署名鍵で token を発行する test 用 IdP。
+type Issuer struct {
+	// 署名アルゴリズム。
+	Alg jose.SignatureAlgorithm
+	// JWKS と token ヘッダの kid。
+	KeyID string
+	// 公開鍵（JWKS 配信用）。
+	public any
+	// 署名器。
+	signer jose.Signer
+}
+
+// IssuerOption は NewIssuer の任意設定。
+type IssuerOption func(*Issuer)
+
+// WithKeyID は kid を指定する（鍵ローテーション test で新旧の kid を分ける）。
+func WithKeyID(kid string) IssuerOption {
+	return func(i *Issuer) { i.KeyID = kid }
+}
+
+// NewIssuer は alg 用の鍵ペアを生成する。HS* 等の非公開鍵系 alg は test 失敗にする。
+// kid の既定は "test-<alg>"。
+func NewIssuer(t testing.TB, alg jose.SignatureAlgorithm, opts ...IssuerOption) *Issuer {
+	t.Helper()
+	priv, pub, err := generateKey(alg)
+	if err != nil {
+		t.Fatalf("authtest: generate %s key: %v", alg, err)
+	}
+	i := &Issuer{Alg: alg, KeyID: "test-" + strings.ToLower(string(alg)), public: pub}
+	for _, opt := range opts {
+		opt(i)
+	}
+	i.signer, err = jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: jose.JSONWebKey{Key: priv, KeyID: i.KeyID}},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatalf("authtest: signer: %v", err)
+	}
+	// 発行者を返す。
+	return i
+}
+
+// generateKey は alg に対応する鍵ペアを返す。
+func generateKey(alg jose.SignatureAlgorithm) (priv, pub any, err error) {
+	switch alg {
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, nil, err
+		}
+		return k, &k.PublicKey, nil
+	case jose.ES256, jose.ES384, jose.ES512:
+		curve := map[jose.SignatureAlgorithm]elliptic.Curve{jose.ES256: elliptic.P256(), jose.ES384: elliptic.P384(), jose.ES512: elliptic.P521()}[alg]
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return k, &k.PublicKey, nil
+	case jose.EdDSA:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		return priv, pub, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported algorithm %s (use HMACToken for HS*)", alg)
+	}
+}
+
+// Token は c の内容で署名した compact JWT を返す。
+func (i *Issuer) Token(t testing.TB, c Claims) string {
+	t.Helper()
+	return sign(t, i.signer, c)
+}
+
+// JWKS は公開鍵 1 本の JWKS JSON を返す（Config.JWKSJSON 用）。
+func (i *Issuer) JWKS(t testing.TB) []byte {
+	t.Helper()
+	raw, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: i.public, KeyID: i.KeyID, Algorithm: string(i.Alg), Use: "sig"}}})
+	if err != nil {
+		t.Fatalf("authtest: marshal jwks: %v", err)
+	}
+	return raw
+}
+
+// ServeJWKS は JWKS を配信する test server を起動し URL を返す（Config.JWKSURL 用、test 終了時に停止）。
+func (i *Issuer) ServeJWKS(t testing.TB) string {
+	t.Helper()
+	raw := i.JWKS(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(raw)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// HMACToken は secret の HS256 で署名した token を返す（mode=hmac 用）。
+func HMACToken(t testing.TB, secret []byte, c Claims) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatalf("authtest: signer: %v", err)
+	}
+	return sign(t, signer, c)
+}
+
+// sign は signer で c を署名する。
+func sign(t testing.TB, signer jose.Signer, c Claims) string {
+	t.Helper()
+	tok, err := jwt.Signed(signer).Claims(c.payload()).Serialize()
+	if err != nil {
+		t.Fatalf("authtest: sign: %v", err)
+	}
+	return tok
+}
@@ -0,0 +1,91 @@
+// 本ファイルは authtest が発行する token を shared/auth が受け付けることの確認テスト。
+//
+// テスト観点:
+//   - 各 alg の Issuer token が JWKS URL / ローカル JWKS で検証を通る
+//   - roles / scopes / iss が context に載る、既定の sub / tenant_id が補われる
+//   - HMACToken が hmac mode で通り、負の TTL は期限切れで 401
+//   - WithKeyID の kid が JWKS / token ヘッダに載り、Extra の nil はクレームを省く
+
+package authtest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth"
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// serve は cfg の middleware に token を通し、status と後段で見えた context の内容を返す。
+func serve(cfg auth.Config, token string) (int, *http.Request) {
+	var seen *http.Request
+	h := auth.RequiredWithConfig(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, seen
+}
+
+func TestIssuerTokensVerify(t *testing.T) {
+	for _, alg := range []jose.SignatureAlgorithm{jose.RS256, jose.PS256, jose.ES256, jose.ES384, jose.EdDSA} {
+		iss := authtest.NewIssuer(t, alg)
+		tok := iss.Token(t, authtest.Claims{})
+		if code, _ := serve(auth.Config{Mode: auth.AuthModeJWKS, JWKSURL: iss.ServeJWKS(t)}, tok); code != http.StatusOK {
+			t.Errorf("%s via JWKS URL: status %d", alg, code)
+		}
+		if code, _ := serve(auth.Config{Mode: auth.AuthModeJWKS, JWKSJSON: iss.JWKS(t)}, tok); code != http.StatusOK {
+			t.Errorf("%s via static JWKS: status %d", alg, code)
+		}
+	}
+}
+
+func TestClaimsReachContext(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.ES256)
+	tok := iss.Token(t, authtest.Claims{Issuer: "https://kc/realms/k1s0", Roles: []string{"admin"}, Scopes: []string{"stock:read"}})
+	code, r := serve(auth.Config{Mode: auth.AuthModeJWKS, JWKSJSON: iss.JWKS(t)}, tok)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	ctx := r.Context()
+	if auth.SubjectFromContext(ctx) != authtest.DefaultSubject || auth.TenantIDFromContext(ctx) != authtest.DefaultTenantID {
+		t.Errorf("defaults not applied: sub=%q tenant=%q", auth.SubjectFromContext(ctx), auth.TenantIDFromContext(ctx))
+	}
+	if !auth.HasRole(ctx, "admin") || !auth.HasScope(ctx, "stock:read") || auth.IssuerFromContext(ctx) != "https://kc/realms/k1s0" {
+		t.Errorf("roles=%v scopes=%v iss=%q", auth.RolesFromContext(ctx), auth.ScopesFromContext(ctx), auth.IssuerFromContext(ctx))
+	}
+}
+
+func TestHMACTokenAndExpiry(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	cfg := auth.Config{Mode: auth.AuthModeHMAC, HMACSecret: secret}
+	if code, _ := serve(cfg, authtest.HMACToken(t, secret, authtest.Claims{})); code != http.StatusOK {
+		t.Errorf("hmac token: status %d", code)
+	}
+	if code, _ := serve(cfg, authtest.HMACToken(t, secret, authtest.Claims{TTL: -time.Hour})); code != http.StatusUnauthorized {
+		t.Errorf("expired token should be 401, got %d", code)
+	}
+}
+
+func TestKeyIDAndOmittedClaim(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.ES256, authtest.WithKeyID("rotated"))
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(iss.JWKS(t), &set); err != nil || len(set.Key("rotated")) != 1 {
+		t.Fatalf("jwks should carry kid rotated: err=%v", err)
+	}
+	cfg := auth.Config{Mode: auth.AuthModeJWKS, JWKSJSON: iss.JWKS(t)}
+	if code, _ := serve(cfg, iss.Token(t, authtest.Claims{})); code != http.StatusOK {
+		t.Errorf("token with custom kid: status %d", code)
+	}
+	// tenant_id を省いた token は shared/auth が 401 にする。
+	if code, _ := serve(cfg, iss.Token(t, authtest.Claims{Extra: map[string]any{"tenant_id": nil}})); code != http.StatusUnauthorized {
+		t.Errorf("token without tenant_id should be 401, got %d", code)
+	}
+}
@@ -0,0 +1,69 @@
+// 本ファイルは tier2 共通 auth middleware のアプリ固有クレーム写像 hook。
+//
+// 役割:
+//   tenant_id / roles / scope 以外の非標準クレーム（department、独自 scope 等）を、
+//   各サービスが map[string]any を手で型アサーションせずに型付き struct で扱えるようにする。
+//     - Config.ClaimsMapper は署名 / 期限 / tenant_id 等の標準検証が通った後に 1 回呼ばれる
+//     - 戻り値は ExtensionKey で context に attach し、ExtensionFromContext[T] で取り出す
+//     - mapper がエラーを返した要求は 401 で拒否する（必須の独自クレーム欠落を fail closed にする）
+//   off mode は検証済クレームを持たないため mapper を呼ばない。
+//
+// 利用例:
+//   cfg.ClaimsMapper = func(c map[string]any) (any, error) {
+//       dept, _ := c["department"].(string)
+//       return Ext{Department: dept}, nil
+//   }
+//   ext, ok := t2auth.ExtensionFromContext[Ext](r.Context())
+
+package auth
+
+// 標準 import。
+import (
+	// context 伝搬。
+	"context"
+	// エラー文字列整形。
+	"fmt"
+)
+
+// ClaimsMapper は検証済 payload の全クレームからアプリ固有の拡張値を作る hook。
+// claims は JSON decode 結果（数値は float64、配列は []any）で、mapper 側で変更しないこと。
+type ClaimsMapper func(claims map[string]any) (any, error)
+
+// ExtensionKey は ClaimsMapper の戻り値を context から取り出すキー。
+const ExtensionKey contextKey = "k1s0.extension"
+
+// mapClaims は cfg.ClaimsMapper を適用する。mapper 未設定 / off mode では mapped=false。
+func mapClaims(cfg Config, id *identity) (ext any, mapped bool, err error) {
+	// 写像対象が無ければ何もしない。
+	if cfg.ClaimsMapper == nil || id.raw == nil {
+		// 未写像を返す。
+		return nil, false, nil
+	}
+	// mapper を呼ぶ。
+	ext, err = cfg.ClaimsMapper(id.raw)
+	if err != nil {
+		// 401 のメッセージに載せる。
+		return nil, false, fmt.Errorf("claims mapping: %w", err)
+	}
+	// 写像結果を返す。
+	return ext, true, nil
+}
+
+// ExtensionFromContext は ClaimsMapper が返した拡張値を T として取り出す。
+// 未設定または型不一致の場合は ok=false。
+func ExtensionFromContext[T any](ctx context.Context) (T, bool) {
+	// nil context 防御。
+	var zero T
+	if ctx == nil {
+		// ゼロ値を返す。
+		return zero, false
+	}
+	// 型アサーション。
+	v, ok := ctx.Value(ExtensionKey).(T)
+	if !ok {
+		// ゼロ値を返す。
+		return zero, false
+	}
+	// 値を返す。
+	return v, true
+}
@@ -0,0 +1,68 @@
+// 本ファイルはアプリ固有クレーム写像 hook の単体テスト。
+//
+// テスト観点:
+//   - ClaimsMapper の戻り値を ExtensionFromContext[T] で型付きに取り出せる
+//   - mapper のエラーは 401
+//   - mapper 未設定 / off mode では拡張値が無い
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// deptExt は test 用の拡張 struct。
+type deptExt struct {
+	Department string
+}
+
+// serveMapped は cfg で token を通し、status と取り出した拡張値を返す。
+func serveMapped(cfg Config, token string) (int, deptExt, bool) {
+	var ext deptExt
+	var ok bool
+	rec := serve(RequiredWithConfig(cfg), func(w http.ResponseWriter, r *http.Request) {
+		ext, ok = ExtensionFromContext[deptExt](r.Context())
+	}, bearerRequest(token))
+	return rec.Code, ext, ok
+}
+
+func TestClaimsMapper_AttachesExtension(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	cfg := Config{Mode: AuthModeHMAC, HMACSecret: secret, ClaimsMapper: func(c map[string]any) (any, error) {
+		dept, _ := c["department"].(string)
+		return deptExt{Department: dept}, nil
+	}}
+	code, ext, ok := serveMapped(cfg, authtest.HMACToken(t, secret, authtest.Claims{Extra: map[string]any{"department": "logistics"}}))
+	if code != http.StatusOK || !ok || ext.Department != "logistics" {
+		t.Fatalf("code=%d ok=%v ext=%+v", code, ok, ext)
+	}
+}
+
+func TestClaimsMapper_ErrorIs401(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	cfg := Config{Mode: AuthModeHMAC, HMACSecret: secret, ClaimsMapper: func(map[string]any) (any, error) {
+		return nil, errors.New("department required")
+	}}
+	if code, _, _ := serveMapped(cfg, authtest.HMACToken(t, secret, authtest.Claims{})); code != http.StatusUnauthorized {
+		t.Fatalf("mapper error should be 401, got %d", code)
+	}
+}
+
+func TestClaimsMapper_AbsentWithoutMapperOrInOffMode(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	if _, _, ok := serveMapped(Config{Mode: AuthModeHMAC, HMACSecret: secret}, authtest.HMACToken(t, secret, authtest.Claims{})); ok {
+		t.Errorf("no extension expected without mapper")
+	}
+	called := false
+	off := Config{Mode: AuthModeOff, ClaimsMapper: func(map[string]any) (any, error) {
+		called = true
+		return deptExt{}, nil
+	}}
+	if code, _, ok := serveMapped(off, "anything"); code != http.StatusOK || ok || called {
+		t.Errorf("off mode should not call mapper: code=%d ok=%v called=%v", code, ok, called)
+	}
+}
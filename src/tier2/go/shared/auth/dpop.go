@@ -0,0 +1,322 @@
+// 本ファイルは tier2 共通 auth middleware の DPoP（RFC 9449）proof 検証。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-001
+//
+// 役割:
+//   高セキュリティ API 向けに、漏洩した access token を第三者が再利用できないよう
+//   token をクライアント鍵に束縛する（opt-in。Config.DPoP が nil なら従来の Bearer のみ）。
+//   Config.DPoP 設定時の Required は以下を全て満たす要求だけを通す:
+//     - `Authorization: DPoP <token>`（Bearer scheme は拒否）と `DPoP: <proof>` ヘッダ
+//     - proof は typ=dpop+jwt、公開鍵 jwk ヘッダ付きで、その鍵の署名が正しい
+//     - htm / htu が要求の method / URL（query・fragment・既定 port 除く、path は encode のまま）と一致
+//     - iat が MaxAge 以内、jti が ReplayStore 上で未使用
+//     - ath が access token の SHA-256、jwk の thumbprint が token の cnf.jkt と一致
+//   失敗時は 401 に `WWW-Authenticate: DPoP error="invalid_dpop_proof"` を付ける。
+//   既定の memory replay store は fail closed: 1 つの proof 鍵（jkt）が保持上限分の未失効 jti を
+//   使い切ると、その鍵の要求は失効まで 401 になる（他の鍵の要求には影響しない）。全体上限到達時は
+//   最も早く失効する jti から捨てる。
+//   off mode では token を検証しないため DPoP 検証も行わない。
+
+package auth
+
+// 標準 / 外部 import。
+import (
+	// replay store の期限順 queue。
+	"container/heap"
+	// context 伝搬。
+	"context"
+	// thumbprint / ath の hash 指定。
+	"crypto"
+	// ath 計算。
+	"crypto/sha256"
+	// base64url 符号化。
+	"encoding/base64"
+	// エラー生成。
+	"errors"
+	// エラー文字列整形。
+	"fmt"
+	// HTTP server。
+	"net/http"
+	// htu 正規化。
+	"net/url"
+	// 文字列処理。
+	"strings"
+	// replay store の排他制御。
+	"sync"
+	// iat 検証。
+	"time"
+
+	// JOSE 実装。
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// dpopProofType は DPoP proof の typ ヘッダ値。
+const dpopProofType = "dpop+jwt"
+
+// DPoPReplayStore は proof の jti 再利用を検出する store（複数 replica では共有 store を実装する）。
+type DPoPReplayStore interface {
+	// Use は proof 鍵 jkt の jti が未使用なら expiresAt まで記録して true、既に使用済なら false を返す。
+	// error を返した要求は拒否される（fail closed）。
+	Use(ctx context.Context, jkt, jti string, expiresAt time.Time) (bool, error)
+}
+
+// DPoPConfig は DPoP proof 検証の設定。
+type DPoPConfig struct {
+	// jti の再利用検出先。nil ならプロセス内 memory store（単一 replica 向け）。
+	ReplayStore DPoPReplayStore
+	// proof の iat 許容経過時間。0 で 60 秒既定。
+	MaxAge time.Duration
+	// htu 照合に使う外部公開 URL（"https://api.example.com"）。空なら要求の TLS / Host から組み立てる。
+	BaseURL string
+	// proof に受け付ける署名アルゴリズム。空なら jwks mode と同じ公開鍵系全て。
+	AllowedAlgorithms []jose.SignatureAlgorithm
+}
+
+// dpopVerifier は既定値を補った DPoPConfig。
+type dpopVerifier struct {
+	replay  DPoPReplayStore
+	maxAge  time.Duration
+	baseURL string
+	algs    []jose.SignatureAlgorithm
+}
+
+// newDPoPVerifier は cfg から verifier を作る。DPoP 未設定 / off mode では nil。
+func newDPoPVerifier(cfg Config) *dpopVerifier {
+	// opt-in でなければ不要。
+	if cfg.DPoP == nil || cfg.Mode == AuthModeOff {
+		// nil を返す。
+		return nil
+	}
+	// 既定値を補う。
+	v := &dpopVerifier{replay: cfg.DPoP.ReplayStore, maxAge: cfg.DPoP.MaxAge, baseURL: strings.TrimSuffix(cfg.DPoP.BaseURL, "/")}
+	if v.replay == nil {
+		v.replay = NewMemoryDPoPReplayStore()
+	}
+	if v.maxAge <= 0 {
+		v.maxAge = time.Minute
+	}
+	// 公開鍵系との積集合を取り、HS* / none を proof に使わせない。
+	v.algs = Config{AllowedAlgorithms: cfg.DPoP.AllowedAlgorithms}.algorithmsFor(jwksAlgorithms)
+	// 組み立てた verifier を返す。
+	return v
+}
+
+// dpopProofClaims は proof payload のクレーム（jti / iat は jwt.Claims）。
+type dpopProofClaims struct {
+	// HTTP method。
+	HTM string `json:"htm"`
+	// HTTP URI。
+	HTU string `json:"htu"`
+	// access token の SHA-256（base64url）。
+	ATH string `json:"ath"`
+	// JWT 標準クレーム。
+	jwt.Claims
+}
+
+// confirmationKey は cnf.jkt を返す（nil-safe）。
+func (c *authClaims) confirmationKey() string {
+	// cnf 不在は空。
+	if c.Cnf == nil {
+		// 空を返す。
+		return ""
+	}
+	// thumbprint を返す。
+	return c.Cnf.JKT
+}
+
+// verify は r の DPoP proof を検証する。token は access token、jkt は token の cnf.jkt。
+func (v *dpopVerifier) verify(r *http.Request, token, jkt string) error {
+	// token が鍵に束縛されていなければ DPoP 要求として不正。
+	if jkt == "" {
+		return errors.New("dpop: token has no cnf.jkt binding")
+	}
+	// proof は 1 つだけ。
+	proofs := r.Header.Values("DPoP")
+	if len(proofs) != 1 {
+		return errors.New("dpop: exactly one DPoP proof header required")
+	}
+	parsed, err := jwt.ParseSigned(proofs[0], v.algs)
+	if err != nil {
+		return fmt.Errorf("dpop: parse: %w", err)
+	}
+	hdr := parsed.Headers[0]
+	if typ, _ := hdr.ExtraHeaders[jose.HeaderType].(string); typ != dpopProofType {
+		return errors.New("dpop: typ must be " + dpopProofType)
+	}
+	if hdr.JSONWebKey == nil || !hdr.JSONWebKey.IsPublic() {
+		return errors.New("dpop: proof must carry a public jwk")
+	}
+	// 埋め込み公開鍵で署名を検証する。
+	var claims dpopProofClaims
+	if err := parsed.Claims(hdr.JSONWebKey.Key, &claims); err != nil {
+		return fmt.Errorf("dpop: verify: %w", err)
+	}
+	// 鍵束縛: proof の鍵が token の cnf.jkt と一致すること。
+	thumb, err := hdr.JSONWebKey.Thumbprint(crypto.SHA256)
+	if err != nil || base64.RawURLEncoding.EncodeToString(thumb) != jkt {
+		return errors.New("dpop: proof key does not match cnf.jkt")
+	}
+	// 要求との対応。
+	if claims.HTM != r.Method {
+		return errors.New("dpop: htm mismatch")
+	}
+	if normalizeHTU(claims.HTU) != v.requestURL(r) {
+		return errors.New("dpop: htu mismatch")
+	}
+	ath := sha256.Sum256([]byte(token))
+	if claims.ATH != base64.RawURLEncoding.EncodeToString(ath[:]) {
+		return errors.New("dpop: ath mismatch")
+	}
+	// 鮮度: 未来は leeway まで、過去は MaxAge まで。
+	if claims.IssuedAt == nil || claims.ID == "" {
+		return errors.New("dpop: iat and jti required")
+	}
+	iat := claims.IssuedAt.Time()
+	if now := time.Now(); iat.After(now.Add(30*time.Second)) || iat.Before(now.Add(-v.maxAge)) {
+		return errors.New("dpop: proof expired or issued in the future")
+	}
+	// replay 検出（MaxAge を過ぎた proof は鮮度検証で落ちるため、それ以上保持しない）。
+	fresh, err := v.replay.Use(r.Context(), jkt, claims.ID, iat.Add(v.maxAge))
+	if err != nil {
+		return fmt.Errorf("dpop: replay store: %w", err)
+	}
+	if !fresh {
+		return errors.New("dpop: proof replayed")
+	}
+	// 全て満たした。
+	return nil
+}
+
+// requestURL は htu 照合用に要求の URL（scheme://host/path）を組み立てる。
+// path はクライアントが送った percent-encoding のまま使う（decode 済 Path では不一致になる）。
+func (v *dpopVerifier) requestURL(r *http.Request) string {
+	// 外部 URL 指定を優先する（TLS 終端 proxy の背後向け）。
+	if v.baseURL != "" {
+		return normalizeHTU(v.baseURL + r.URL.EscapedPath())
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	// 正規化して返す。
+	return normalizeHTU(scheme + "://" + r.Host + r.URL.EscapedPath())
+}
+
+// normalizeHTU は query / fragment と既定 port（https の 443 / http の 80）を除き、
+// scheme / host を小文字化する（RFC 9449 §4.3）。
+func normalizeHTU(raw string) string {
+	// 解析できない値は一致させない。
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	// 比較対象外の部分を落とす。
+	u.RawQuery, u.Fragment, u.RawFragment = "", "", ""
+	u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	if (u.Scheme == "https" && u.Port() == "443") || (u.Scheme == "http" && u.Port() == "80") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+u.Port())
+	}
+	// 文字列に戻す。
+	return u.String()
+}
+
+// memoryReplayMaxEntries は memory store が保持する jti の上限（超過時は最も早く失効する jti を捨てる）。
+const memoryReplayMaxEntries = 100000
+
+// memoryReplayMaxPerKey は 1 つの proof 鍵が同時に保持できる jti の上限（超過時はその鍵だけ fail closed）。
+const memoryReplayMaxPerKey = 10000
+
+// errReplayQuotaExceeded は proof 鍵ごとの上限に達したことを示す。
+var errReplayQuotaExceeded = errors.New("memory replay store: per-key quota exceeded")
+
+// replayKey は jti の記録単位（RFC 9449 では jti は proof 鍵ごとに一意）。
+type replayKey struct {
+	jkt string
+	jti string
+}
+
+// replayEntry は期限順 queue 上の jti 1 件。
+type replayEntry struct {
+	key       replayKey
+	expiresAt time.Time
+}
+
+// replayQueue は expiresAt 昇順の min-heap（container/heap 実装）。
+type replayQueue []replayEntry
+
+func (q replayQueue) Len() int           { return len(q) }
+func (q replayQueue) Less(i, j int) bool { return q[i].expiresAt.Before(q[j].expiresAt) }
+func (q replayQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *replayQueue) Push(x any)        { *q = append(*q, x.(replayEntry)) }
+func (q *replayQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// memoryDPoPReplayStore はプロセス内の jti 使用記録。
+type memoryDPoPReplayStore struct {
+	mu   sync.Mutex
+	seen map[replayKey]struct{}
+	// perKey は proof 鍵ごとの保持件数。
+	perKey map[string]int
+	// queue は失効順の jti（先頭から期限切れを取り除く）。
+	queue replayQueue
+	// max / maxPerKey は保持上限（test で縮められるよう field に持つ）。
+	max       int
+	maxPerKey int
+}
+
+// NewMemoryDPoPReplayStore はプロセス内 memory の DPoPReplayStore を返す（単一 replica 向け）。
+func NewMemoryDPoPReplayStore() DPoPReplayStore {
+	// 空の記録を返す。
+	return &memoryDPoPReplayStore{
+		seen:      map[replayKey]struct{}{},
+		perKey:    map[string]int{},
+		max:       memoryReplayMaxEntries,
+		maxPerKey: memoryReplayMaxPerKey,
+	}
+}
+
+// Use は jti を記録する。期限切れは queue 先頭から取り除くため、1 回あたり O(log n) で済む。
+// 鍵ごとの上限に達した場合は古い jti を捨てて replay を許すより、error でその鍵の要求を拒否する。
+// 全体上限は多数の鍵による memory 枯渇への備えで、最も早く失効する jti から捨てる。
+func (s *memoryDPoPReplayStore) Use(_ context.Context, jkt, jti string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 上限判定の前に期限切れを掃除する。
+	now := time.Now()
+	for len(s.queue) > 0 && now.After(s.queue[0].expiresAt) {
+		s.pop()
+	}
+	// 既出なら replay。
+	key := replayKey{jkt: jkt, jti: jti}
+	if _, ok := s.seen[key]; ok {
+		return false, nil
+	}
+	if s.perKey[jkt] >= s.maxPerKey {
+		return false, errReplayQuotaExceeded
+	}
+	// 全体上限では失効の近い jti から捨てる。
+	for len(s.queue) >= s.max {
+		s.pop()
+	}
+	// 記録して初回を返す。
+	s.seen[key] = struct{}{}
+	s.perKey[jkt]++
+	heap.Push(&s.queue, replayEntry{key: key, expiresAt: expiresAt})
+	return true, nil
+}
+
+// pop は最も早く失効する jti を記録から取り除く。
+func (s *memoryDPoPReplayStore) pop() {
+	e := heap.Pop(&s.queue).(replayEntry)
+	delete(s.seen, e.key)
+	// 件数 0 の鍵は map から消す。
+	if s.perKey[e.key.jkt]--; s.perKey[e.key.jkt] <= 0 {
+		delete(s.perKey, e.key.jkt)
+	}
+}
@@ -0,0 +1,191 @@
+// 本ファイルは DPoP proof 検証の単体テスト。
+//
+// テスト観点:
+//   - 正しい proof + DPoP scheme の束縛 token は通過する
+//   - 同一 proof の再送（jti 再利用）は 401
+//   - htm / htu / ath / 鍵不一致 / 古い iat / proof 欠落 / Bearer scheme は 401
+//   - DPoP 未設定時は従来どおり Bearer を受ける
+//   - htu は percent-encoding を保ち、既定 port を無視して照合する
+//   - memory replay store は上限判定の前に期限切れを取り除き、鍵ごとの上限超過はその鍵だけ error、
+//     全体上限では最も早く失効する jti を捨てる
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// dpopClient は test 用の DPoP クライアント鍵と束縛 access token。
+type dpopClient struct {
+	key   *ecdsa.PrivateKey
+	token string
+}
+
+// newDPoPClient は鍵を作り、その thumbprint を cnf.jkt に持つ HS256 access token を発行する。
+func newDPoPClient(t *testing.T, secret []byte) *dpopClient {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa: %v", err)
+	}
+	thumb, err := (&jose.JSONWebKey{Key: &key.PublicKey}).Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("thumbprint: %v", err)
+	}
+	tok := authtest.HMACToken(t, secret, authtest.Claims{Subject: "u1", TenantID: "T1", JKT: base64.RawURLEncoding.EncodeToString(thumb), TTL: time.Minute})
+	return &dpopClient{key: key, token: tok}
+}
+
+// proof は key で署名した DPoP proof を作る。mutate で payload を改変できる。
+func (c *dpopClient) proof(t *testing.T, key *ecdsa.PrivateKey, method, htu, jti string, mutate func(map[string]any)) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType(dpopProofType))
+	if err != nil {
+		t.Fatalf("signer: %v", err)
+	}
+	ath := sha256.Sum256([]byte(c.token))
+	payload := map[string]any{
+		"htm": method, "htu": htu, "jti": jti, "iat": time.Now().Unix(),
+		"ath": base64.RawURLEncoding.EncodeToString(ath[:]),
+	}
+	if mutate != nil {
+		mutate(payload)
+	}
+	p, err := jwt.Signed(signer).Claims(payload).Serialize()
+	if err != nil {
+		t.Fatalf("sign proof: %v", err)
+	}
+	return p
+}
+
+// dpopRequest は Authorization scheme / token と proof（空なら付けない）を付けた POST /stock を返す。
+func dpopRequest(scheme, token, proof string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "https://api.example.com/stock?x=1", nil)
+	req.Header.Set("Authorization", scheme+" "+token)
+	if proof != "" {
+		req.Header.Set("DPoP", proof)
+	}
+	return req
+}
+
+func TestDPoP_AcceptsValidProofAndRejectsReplay(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	c := newDPoPClient(t, secret)
+	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret, DPoP: &DPoPConfig{}})
+	proof := c.proof(t, c.key, http.MethodPost, "https://API.example.com/stock", "j1", nil)
+	if rec := serve(mw, nil, dpopRequest("DPoP", c.token, proof)); rec.Code != http.StatusOK {
+		t.Fatalf("valid proof should pass, got %d", rec.Code)
+	}
+	if rec := serve(mw, nil, dpopRequest("DPoP", c.token, proof)); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("replayed proof should be 401 with challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestDPoP_Rejects(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	c := newDPoPClient(t, secret)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa: %v", err)
+	}
+	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret, DPoP: &DPoPConfig{}})
+	const htu = "https://api.example.com/stock"
+	cases := map[string]struct{ scheme, proof string }{
+		"htm mismatch":  {"DPoP", c.proof(t, c.key, http.MethodGet, htu, "a", nil)},
+		"htu mismatch":  {"DPoP", c.proof(t, c.key, http.MethodPost, "https://api.example.com/other", "b", nil)},
+		"ath mismatch":  {"DPoP", c.proof(t, c.key, http.MethodPost, htu, "c", func(p map[string]any) { p["ath"] = "x" })},
+		"key not bound": {"DPoP", c.proof(t, other, http.MethodPost, htu, "d", nil)},
+		"stale iat":     {"DPoP", c.proof(t, c.key, http.MethodPost, htu, "e", func(p map[string]any) { p["iat"] = time.Now().Add(-time.Hour).Unix() })},
+		"missing jti":   {"DPoP", c.proof(t, c.key, http.MethodPost, htu, "", nil)},
+		"missing proof": {"DPoP", ""},
+		"bearer scheme": {"Bearer", c.proof(t, c.key, http.MethodPost, htu, "f", nil)},
+	}
+	for name, tc := range cases {
+		if rec := serve(mw, nil, dpopRequest(tc.scheme, c.token, tc.proof)); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", name, rec.Code)
+		}
+	}
+}
+
+func TestDPoP_DisabledKeepsBearer(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	c := newDPoPClient(t, secret)
+	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret})
+	if rec := serve(mw, nil, dpopRequest("Bearer", c.token, "")); rec.Code != http.StatusOK {
+		t.Fatalf("bearer should pass without DPoP config, got %d", rec.Code)
+	}
+}
+
+func TestNormalizeHTU(t *testing.T) {
+	cases := map[string]string{
+		"https://API.example.com:443/a%2Fb?x=1#f": "https://api.example.com/a%2Fb",
+		"http://api.example.com:80/stock":         "http://api.example.com/stock",
+		"https://api.example.com:8443/stock":      "https://api.example.com:8443/stock",
+		"http://api.example.com:443/stock":        "http://api.example.com:443/stock",
+	}
+	for raw, want := range cases {
+		if got := normalizeHTU(raw); got != want {
+			t.Errorf("normalizeHTU(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestDPoP_RequestURLKeepsEscapedPath(t *testing.T) {
+	v := &dpopVerifier{}
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/items/a%2Fb", nil)
+	if got, want := v.requestURL(req), normalizeHTU("https://api.example.com:443/items/a%2Fb"); got != want {
+		t.Fatalf("requestURL = %q, want %q", got, want)
+	}
+}
+
+func TestMemoryDPoPReplayStore_ExpiresAndCaps(t *testing.T) {
+	s := NewMemoryDPoPReplayStore().(*memoryDPoPReplayStore)
+	s.max, s.maxPerKey = 2, 2
+	ctx := context.Background()
+	now := time.Now()
+	if ok, err := s.Use(ctx, "k1", "old", now.Add(-time.Second)); !ok || err != nil {
+		t.Fatalf("first use: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := s.Use(ctx, "k1", "a", now.Add(time.Minute)); !ok {
+		t.Fatalf("a should be fresh")
+	}
+	// 期限切れの old は上限判定の前に取り除かれ、その分を b が使える。
+	if ok, err := s.Use(ctx, "k1", "b", now.Add(2*time.Minute)); !ok || err != nil {
+		t.Fatalf("b should fit after expiry: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := s.Use(ctx, "k1", "a", now.Add(time.Minute)); ok {
+		t.Fatalf("a should be detected as replay")
+	}
+	// 鍵ごとの上限到達時はその鍵だけ fail closed。
+	if ok, err := s.Use(ctx, "k1", "c", now.Add(time.Minute)); ok || !errors.Is(err, errReplayQuotaExceeded) {
+		t.Fatalf("k1 over quota should error: ok=%v err=%v", ok, err)
+	}
+	// 別の鍵は通り、全体上限では最も早く失効する k1/a を捨てる。
+	if ok, err := s.Use(ctx, "k2", "a", now.Add(time.Minute)); !ok || err != nil {
+		t.Fatalf("k2 should not be locked out by k1: ok=%v err=%v", ok, err)
+	}
+	if _, ok := s.seen[replayKey{jkt: "k1", jti: "a"}]; ok || len(s.seen) != 2 {
+		t.Fatalf("global cap should evict k1/a first: seen=%v", s.seen)
+	}
+	// k1 は枠が空いたので再び使える。
+	if ok, err := s.Use(ctx, "k1", "c", now.Add(time.Minute)); !ok || err != nil {
+		t.Fatalf("k1 should fit after eviction: ok=%v err=%v", ok, err)
+	}
+}
@@ -0,0 +1,87 @@
+// 本ファイルは tier2 共通 auth middleware の環境変数からの設定読込。
+//
+// docs 正典:
+//   docs/03_要件定義/00_共通規約.md §「認証認可」
+//
+// 役割:
+//   Required が使う Config を T2_AUTH_* 環境変数から組み立てる（一覧は src/tier2/go/README.md）。
+//   T2_AUTH_JWKS_FILE の読込失敗は Config に保持し、検証時に fail closed させる。
+
+package auth
+
+// 標準 / 外部 import。
+import (
+	// エラー文字列整形。
+	"fmt"
+	// HTTP client 既定値。
+	"net/http"
+	// 環境変数 / ファイル読込。
+	"os"
+	// 文字列処理。
+	"strings"
+	// JWKS cache TTL。
+	"time"
+
+	// 署名アルゴリズム名。
+	"github.com/go-jose/go-jose/v4"
+)
+
+// LoadConfigFromEnv は環境変数から Config を構築する。
+//
+// 既定 Mode は off（dev 既定）。production では T2_AUTH_MODE=jwks を必ず設定する。
+func LoadConfigFromEnv() Config {
+	mode := AuthMode(os.Getenv("T2_AUTH_MODE"))
+	if mode == "" {
+		mode = AuthModeOff
+	}
+	// T2_AUTH_JWKS_FILE の読込失敗は保持し、検証時に fail closed させる（本関数は error を返さないため）。
+	jwksJSON, jwksFileErr := readJWKSFile(os.Getenv("T2_AUTH_JWKS_FILE"))
+	return Config{
+		Mode:         mode,
+		HMACSecret:   []byte(os.Getenv("T2_AUTH_HMAC_SECRET")),
+		JWKSURL:      os.Getenv("T2_AUTH_JWKS_URL"),
+		JWKSJSON:     jwksJSON,
+		jwksFileErr:  jwksFileErr,
+		Issuers:      parseIssuers(os.Getenv("T2_AUTH_ISSUERS")),
+		JWKSCacheTTL: 10 * time.Minute,
+		HTTPClient:   http.DefaultClient,
+		// 未設定なら nil（mode 既定集合）。
+		AllowedAlgorithms: parseAlgorithms(os.Getenv("T2_AUTH_ALLOWED_ALGS")),
+	}
+}
+
+// readJWKSFile は path の JWKS JSON を読む。未設定なら nil, nil を返す。
+func readJWKSFile(path string) ([]byte, error) {
+	// 未設定は nil。
+	if path == "" {
+		// nil を返す。
+		return nil, nil
+	}
+	// ファイルを読む。
+	b, err := os.ReadFile(path)
+	if err != nil {
+		// os の error は errors.Is で判別できるよう wrap する。
+		return nil, fmt.Errorf("read T2_AUTH_JWKS_FILE: %w", err)
+	}
+	// 内容を返す。
+	return b, nil
+}
+
+// parseAlgorithms はカンマ区切りのアルゴリズム名を分解する。空要素は無視する。
+func parseAlgorithms(v string) []jose.SignatureAlgorithm {
+	// 未設定は nil。
+	if strings.TrimSpace(v) == "" {
+		// nil を返す。
+		return nil
+	}
+	// 分解して trim する。
+	var out []jose.SignatureAlgorithm
+	for _, item := range strings.Split(v, ",") {
+		// 空要素は読み飛ばす。
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, jose.SignatureAlgorithm(item))
+		}
+	}
+	// 分解結果を返す。
+	return out
+}
@@ -0,0 +1,125 @@
+// 本ファイルは tier2 共通 auth middleware の multi-issuer 対応。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-001
+//
+// 役割:
+//   1 つの tier2 サービスが複数 realm / IdP（社内 Keycloak と partner IdP 等）の token を
+//   受ける構成で、issuer ごとに JWKS を引き分ける。
+//     - Config.Issuers（env T2_AUTH_ISSUERS="iss=url,iss=url"）未設定時は従来の単一 JWKS
+//     - 設定時は未検証の iss で該当 issuer の jwksCache を選び、署名検証後に iss を再照合する
+//       （未登録 iss は鍵取得前に拒否するため、任意 URL への fetch は起こらない）
+//   認証済 token の iss は IssuerKey で context に attach する。
+
+package auth
+
+// 標準 / 外部 import。
+import (
+	// context 伝搬。
+	"context"
+	// エラー生成。
+	"errors"
+	// エラー文字列整形。
+	"fmt"
+	// 文字列処理。
+	"strings"
+
+	// JWT 解析。
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// jwksResolver は token に対応する jwksCache を選ぶ（単一 JWKS / issuer 別 JWKS）。
+type jwksResolver struct {
+	// single は Issuers 未設定時の唯一の cache。
+	single *jwksCache
+	// byIssuer は iss → cache（Issuers 設定時のみ）。
+	byIssuer map[string]*jwksCache
+}
+
+// newJWKSResolver は cfg から resolver を組み立てる。鍵の供給元が無い場合は nil。
+func newJWKSResolver(cfg Config) *jwksResolver {
+	// jwks mode 以外は不要。
+	if cfg.Mode != AuthModeJWKS {
+		// nil を返す。
+		return nil
+	}
+	// issuer 別構成を優先する。
+	if len(cfg.Issuers) > 0 {
+		r := &jwksResolver{byIssuer: make(map[string]*jwksCache, len(cfg.Issuers))}
+		for iss, url := range cfg.Issuers {
+			r.byIssuer[iss] = newRemoteJWKSCache(cfg, url)
+		}
+		// 組み立てた resolver を返す。
+		return r
+	}
+	// 単一 JWKS 構成。
+	single := newJWKSCache(cfg)
+	if single == nil {
+		// 未構成は nil（authenticate が "jwks not configured" を返す）。
+		return nil
+	}
+	// 単一 cache を包んで返す。
+	return &jwksResolver{single: single}
+}
+
+// resolve は token の鍵集合と、検証後に照合すべき iss を返す（単一構成では iss を照合しない）。
+func (r *jwksResolver) resolve(parsed *jwt.JSONWebToken) (*jwksCache, string, error) {
+	// 単一構成はそのまま返す。
+	if r.byIssuer == nil {
+		// iss 照合なし。
+		return r.single, "", nil
+	}
+	// 署名検証前の iss は鍵の選択にのみ使い、検証後に finalizeClaims で再照合する。
+	var unverified jwt.Claims
+	if err := parsed.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, "", fail(ReasonMalformed, fmt.Errorf("parse: %w", err))
+	}
+	if unverified.Issuer == "" {
+		return nil, "", fail(ReasonIssuer, errors.New("missing iss claim"))
+	}
+	cache, ok := r.byIssuer[unverified.Issuer]
+	if !ok {
+		return nil, "", fail(ReasonIssuer, fmt.Errorf("untrusted issuer %q", unverified.Issuer))
+	}
+	// 選んだ cache と照合用の iss を返す。
+	return cache, unverified.Issuer, nil
+}
+
+// parseIssuers は "iss=url,iss=url" 形式を分解する。空要素 / "=" の無い要素は無視する。
+func parseIssuers(v string) map[string]string {
+	// 分解結果。
+	out := map[string]string{}
+	for _, item := range strings.Split(v, ",") {
+		// JWKS URL は query に "=" を含み得るため最初の "=" で分ける。
+		iss, url, ok := strings.Cut(strings.TrimSpace(item), "=")
+		iss, url = strings.TrimSpace(iss), strings.TrimSpace(url)
+		if !ok || iss == "" || url == "" {
+			continue
+		}
+		out[iss] = url
+	}
+	// 未設定は nil（単一 JWKS 構成）。
+	if len(out) == 0 {
+		// nil を返す。
+		return nil
+	}
+	// 分解結果を返す。
+	return out
+}
+
+// IssuerFromContext は middleware が attach した iss を返す（off mode / iss 無し token では空）。
+func IssuerFromContext(ctx context.Context) string {
+	// nil context 防御。
+	if ctx == nil {
+		// 空を返す。
+		return ""
+	}
+	// 型アサーション。
+	v, ok := ctx.Value(IssuerKey).(string)
+	if !ok {
+		// 空を返す。
+		return ""
+	}
+	// 値を返す。
+	return v
+}
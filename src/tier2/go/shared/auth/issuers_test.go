@@ -0,0 +1,66 @@
+// 本ファイルは multi-issuer 対応の単体テスト。
+//
+// テスト観点:
+//   - iss ごとに該当 issuer の JWKS で検証し、IssuerFromContext で iss を取り出せる
+//   - 未登録 iss / iss 無し token は拒否する
+//   - 他 issuer の鍵で署名した token を別 issuer と名乗らせても拒否する
+//   - T2_AUTH_ISSUERS の分解
+
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-jose/go-jose/v4"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+func TestMultiIssuer_VerifiesPerIssuer(t *testing.T) {
+	internal, partner := authtest.NewIssuer(t, jose.ES256), authtest.NewIssuer(t, jose.ES256)
+	cfg := Config{Mode: AuthModeJWKS, Issuers: map[string]string{
+		"https://kc/realms/internal": internal.ServeJWKS(t),
+		"https://idp.partner":        partner.ServeJWKS(t),
+	}}
+	var got string
+	mw := RequiredWithConfig(cfg)
+	next := func(w http.ResponseWriter, r *http.Request) {
+		got = IssuerFromContext(r.Context())
+	}
+	for iss, f := range map[string]*authtest.Issuer{"https://kc/realms/internal": internal, "https://idp.partner": partner} {
+		rec := serve(mw, next, bearerRequest(f.Token(t, authtest.Claims{Issuer: iss})))
+		if rec.Code != http.StatusOK || got != iss {
+			t.Errorf("iss %s: status=%d issuer=%q", iss, rec.Code, got)
+		}
+	}
+}
+
+func TestMultiIssuer_Rejects(t *testing.T) {
+	internal, partner := authtest.NewIssuer(t, jose.ES256), authtest.NewIssuer(t, jose.ES256)
+	cfg := Config{Mode: AuthModeJWKS, Issuers: map[string]string{
+		"https://kc/realms/internal": internal.ServeJWKS(t),
+		"https://idp.partner":        partner.ServeJWKS(t),
+	}}
+	cases := map[string]string{
+		"unknown issuer": internal.Token(t, authtest.Claims{Issuer: "https://evil"}),
+		"missing iss":    internal.Token(t, authtest.Claims{}),
+		// partner の鍵で署名し internal を名乗る token は internal の JWKS で検証され失敗する。
+		"cross-issuer key": partner.Token(t, authtest.Claims{Issuer: "https://kc/realms/internal"}),
+	}
+	for name, tok := range cases {
+		if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(tok)).Code; got != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", name, got)
+		}
+	}
+}
+
+func TestParseIssuers(t *testing.T) {
+	got := parseIssuers(" https://a = https://a/jwks?x=1 , bad, =https://b ,https://c=https://c/jwks")
+	if len(got) != 2 || got["https://a"] != "https://a/jwks?x=1" || got["https://c"] != "https://c/jwks" {
+		t.Fatalf("parseIssuers = %v", got)
+	}
+	if parseIssuers("") != nil {
+		t.Fatalf("empty should be nil")
+	}
+}
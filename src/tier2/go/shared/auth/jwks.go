@@ -0,0 +1,297 @@
+// 本ファイルは tier2 共通 auth middleware の JWKS 取得・キャッシュ。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-001
+//
+// 役割:
+//   mode=jwks の公開鍵集合を TTL 付きで保持する。Keycloak 障害や鍵ローテーションで
+//   認証全体が止まらないよう、以下の 3 点を担う:
+//     - stale-while-revalidate: 期限切れ / 更新失敗時は最後に取得できた鍵集合を返し続ける
+//       （取得は lock 外で同時に 1 本まで・jwksFetchTimeout で打ち切り、要求経路は待たない。
+//       失敗後の再試行は staleRetryInterval 間隔に抑制し、JWKS endpoint を叩き続けない）
+//     - 未知 kid の再取得: ローテーション直後の新 kid を TTL 満了まで拒否しないよう即時再取得する
+//       （偽 kid による増幅を防ぐため kidRefetchInterval に 1 回まで）
+//     - 先行更新: Config.JWKSRefreshContext 設定時は TTL の 75〜100% で jitter 付きに
+//       バックグラウンド更新し、要求経路で fetch 待ちが発生しないようにする
+//   ローカル JWKS（Config.JWKSJSON）の場合は固定値を返し、一切 fetch しない。
+
+package auth
+
+// 標準 / 外部 import。
+import (
+	// context 伝搬。
+	"context"
+	// JWKS decode。
+	"encoding/json"
+	// エラー文字列整形。
+	"fmt"
+	// 先行更新の jitter。
+	"math/rand/v2"
+	// HTTP client。
+	"net/http"
+	// 排他制御。
+	"sync"
+	// TTL 管理。
+	"time"
+
+	// JOSE 実装。
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	// staleRetryInterval は更新失敗後に last-good を返しつつ再試行を待つ間隔。
+	staleRetryInterval = 30 * time.Second
+	// kidRefetchInterval は未知 kid による再取得の最小間隔。
+	kidRefetchInterval = 30 * time.Second
+	// jwksFetchTimeout は JWKS 取得 1 回の上限（応答しない endpoint で更新が滞留しないようにする）。
+	jwksFetchTimeout = 10 * time.Second
+)
+
+// jwksCache は JWKS の TTL 付き cache（複数 goroutine 安全）。
+type jwksCache struct {
+	mu        sync.RWMutex
+	jwks      *jose.JSONWebKeySet
+	expiresAt time.Time
+	url       string
+	ttl       time.Duration
+	client    *http.Client
+	// lastAttempt は直近の取得試行時刻（未知 kid 再取得の抑制に使う）。
+	lastAttempt time.Time
+	// refetchInterval は未知 kid 再取得の最小間隔（test で短縮できるよう field に持つ）。
+	refetchInterval time.Duration
+	// fetchTimeout は取得 1 回の上限（test で短縮できるよう field に持つ）。
+	fetchTimeout time.Duration
+	// refreshing は進行中の取得の完了通知（取得中でなければ nil）。
+	refreshing chan struct{}
+	// lastErr は直近の取得結果（初回取得の失敗を待機側へ返す）。
+	lastErr error
+	// static はローカル JWKS 由来で fetch しないか。
+	static bool
+	// staticErr はローカル JWKS の decode 失敗（全要求を fail closed させる）。
+	staticErr error
+	// metrics は取得遅延 / cache hit の計測先（ローカル JWKS では未使用）。
+	metrics Metrics
+}
+
+// newJWKSCache は cfg から jwksCache を組み立てる。mode=jwks 以外 / 鍵の供給元が無い場合は nil。
+func newJWKSCache(cfg Config) *jwksCache {
+	// jwks mode 以外は不要。
+	if cfg.Mode != AuthModeJWKS {
+		// nil を返す。
+		return nil
+	}
+	// ローカル JWKS ファイルを読めなかった場合は URL に fallback せず fail closed にする。
+	if cfg.jwksFileErr != nil {
+		// 読込 error を保持した cache を返す。
+		return &jwksCache{static: true, staticErr: cfg.jwksFileErr}
+	}
+	// ローカル JWKS を優先する。
+	if len(cfg.JWKSJSON) > 0 {
+		// 固定 cache を返す。
+		return newStaticJWKSCache(cfg.JWKSJSON)
+	}
+	// URL 未設定は未構成扱い（authenticate が "jwks not configured" を返す）。
+	if cfg.JWKSURL == "" {
+		// nil を返す。
+		return nil
+	}
+	// URL から取得する cache を返す。
+	return newRemoteJWKSCache(cfg, cfg.JWKSURL)
+}
+
+// newRemoteJWKSCache は url から取得する cache を cfg の TTL / client / 先行更新設定で組み立てる。
+func newRemoteJWKSCache(cfg Config, url string) *jwksCache {
+	// TTL / client の既定値を補う。
+	ttl := cfg.JWKSCacheTTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := &jwksCache{url: url, ttl: ttl, client: client, refetchInterval: kidRefetchInterval, fetchTimeout: jwksFetchTimeout, metrics: metricsOf(cfg)}
+	// 先行更新は呼出側が寿命（ctx）を与えた場合のみ起動する。
+	if cfg.JWKSRefreshContext != nil {
+		go c.refreshLoop(cfg.JWKSRefreshContext)
+	}
+	// 組み立てた cache を返す。
+	return c
+}
+
+// newStaticJWKSCache はローカル JWKS JSON から fetch しない cache を作る。
+func newStaticJWKSCache(raw []byte) *jwksCache {
+	// decode 失敗は保持して検証時に返す。
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		// fail closed 用の cache を返す。
+		return &jwksCache{static: true, staticErr: fmt.Errorf("static jwks decode: %w", err)}
+	}
+	// 固定の key set を返す。
+	return &jwksCache{static: true, jwks: &keys}
+}
+
+// fetch は有効な鍵集合を返す。期限切れでも last-good があればそれを返し、更新はバックグラウンドで行う。
+func (c *jwksCache) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	// ローカル JWKS は常に固定値を返す。
+	if c.static {
+		// decode 結果を返す。
+		return c.jwks, c.staticErr
+	}
+	// 有効期間内なら read lock のみで返す。
+	c.mu.RLock()
+	j, fresh := c.jwks, time.Now().Before(c.expiresAt)
+	c.mu.RUnlock()
+	if j != nil && fresh {
+		c.metrics.ObserveJWKSCache(true)
+		return j, nil
+	}
+	c.metrics.ObserveJWKSCache(false)
+	// 期限切れの last-good は更新完了を待たずに返す（stale-while-revalidate）。
+	done := c.startRefresh()
+	if j != nil {
+		return j, nil
+	}
+	// 一度も取得できていなければ初回取得を待つ。
+	return c.wait(ctx, done)
+}
+
+// lookup は kid に一致する鍵を返す。未知 kid は鍵ローテーションとみなし、抑制間隔内で 1 回だけ再取得する。
+// 取得が進行中なら抑制間隔によらずその完了を待ってから判定する。
+func (c *jwksCache) lookup(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	// 通常経路で鍵集合を得る。
+	keys, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 既知 kid / ローカル JWKS はそのまま返す。
+	if matches := keys.Key(kid); len(matches) > 0 || c.static {
+		return matches, nil
+	}
+	c.mu.RLock()
+	// 待つ間に他 goroutine が再取得済みなら結果を使う。
+	if matches := c.jwks.Key(kid); len(matches) > 0 {
+		c.mu.RUnlock()
+		return matches, nil
+	}
+	// 直近に取得済みなら再取得しない（偽 kid による JWKS endpoint への増幅を防ぐ）。
+	throttled := time.Since(c.lastAttempt) < c.refetchInterval
+	// 取得中なら抑制間隔内でもその結果を待つ（ローテーション直後の新 kid を取りこぼさない）。
+	var done <-chan struct{} = c.refreshing
+	c.mu.RUnlock()
+	// 未知 kid は鍵ローテーションの兆候のため、再取得の有無によらず cache miss として計測する。
+	c.metrics.ObserveJWKSCache(false)
+	if done == nil {
+		if throttled {
+			return nil, nil
+		}
+		done = c.startRefresh()
+	}
+	// 取得自体が fetchTimeout で打ち切られるため、待機もその範囲に収まる。
+	// 待つのはこの要求のみで、他の要求は lock を取らず現行の鍵集合で検証を続ける。
+	keys, err = c.wait(ctx, done)
+	if err != nil {
+		return nil, err
+	}
+	return keys.Key(kid), nil
+}
+
+// startRefresh は JWKS 取得をバックグラウンドで開始し、完了時に close される channel を返す。
+// 取得中なら新たに開始せず、進行中の取得の channel を返す（同時に 1 本まで）。
+func (c *jwksCache) startRefresh() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshing != nil {
+		return c.refreshing
+	}
+	done := make(chan struct{})
+	c.refreshing = done
+	c.lastAttempt = time.Now()
+	go c.refresh(done)
+	return done
+}
+
+// refresh は lock を持たずに JWKS を取得し、結果の反映のみ短い write lock で行う。
+// 取得失敗時に last-good があれば維持し、staleRetryInterval 後まで再試行しない。
+func (c *jwksCache) refresh(done chan struct{}) {
+	// 取得は要求 ctx と切り離し（複数要求で共有するため）、fetchTimeout で打ち切る。
+	ctx, cancel := context.WithTimeout(context.Background(), c.fetchTimeout)
+	defer cancel()
+	start := time.Now()
+	keys, err := c.download(ctx)
+	c.metrics.ObserveJWKSFetch(time.Since(start), err)
+	c.mu.Lock()
+	defer close(done)
+	defer c.mu.Unlock()
+	c.refreshing = nil
+	c.lastErr = err
+	if err != nil {
+		// last-good を返し続け、再試行間隔を TTL 以下に抑える。
+		if c.jwks != nil {
+			c.expiresAt = time.Now().Add(min(staleRetryInterval, c.ttl))
+		}
+		return
+	}
+	c.jwks = keys
+	c.expiresAt = time.Now().Add(c.ttl)
+}
+
+// wait は done（取得完了）または ctx 終了まで待ち、現行の鍵集合を返す。
+// 取得に失敗し last-good も無い場合は取得 error を返す。
+func (c *jwksCache) wait(ctx context.Context, done <-chan struct{}) (*jose.JSONWebKeySet, error) {
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("jwks fetch: %w", ctx.Err())
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.jwks == nil {
+		return nil, c.lastErr
+	}
+	return c.jwks, nil
+}
+
+// download は JWKS endpoint から鍵集合を取得する。
+func (c *jwksCache) download(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks fetch: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks fetch: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks fetch: HTTP %d", resp.StatusCode)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return nil, fmt.Errorf("jwks decode: %w", err)
+	}
+	return &keys, nil
+}
+
+// refreshLoop は ctx 終了まで TTL の 75〜100% 間隔で先行更新する。
+// 複数 replica が同時刻に JWKS endpoint を叩かないよう jitter を入れる。
+func (c *jwksCache) refreshLoop(ctx context.Context) {
+	for {
+		// 次回更新までの待機時間を決める。
+		wait := c.ttl*3/4 + time.Duration(rand.Int64N(int64(c.ttl/4)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			// 寿命終了で停止する。
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// 失敗時は refresh が last-good を維持する。完了を待って次回の待機を始める。
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.startRefresh():
+		}
+	}
+}
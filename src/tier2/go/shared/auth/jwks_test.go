@@ -0,0 +1,267 @@
+// 本ファイルは tier2 共通 auth middleware の JWKS cache 単体テスト。
+//
+// テスト観点:
+//   - JWKS endpoint 障害時は last-good の鍵集合で検証を継続する
+//   - 応答しない endpoint の取得中も lookup は待たされず、取得は fetchTimeout で打ち切られる
+//   - 未知 kid は即時再取得し、抑制間隔内の連続再取得はしない
+//   - JWKSRefreshContext 設定時はバックグラウンドで鍵集合を更新し、ctx 終了で停止する
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// rotatingJWKS は配信内容と障害状態を test から切り替えられる JWKS server。
+type rotatingJWKS struct {
+	mu   sync.Mutex
+	set  []byte
+	fail bool
+	// block が非 nil の間、応答を block の close まで止める。
+	block chan struct{}
+	hits  atomic.Int32
+	serve *httptest.Server
+}
+
+// newRotatingJWKS は kid の ES256 公開鍵を配信する server を起動する。
+func newRotatingJWKS(t *testing.T, kid string) *rotatingJWKS {
+	t.Helper()
+	r := &rotatingJWKS{}
+	r.setKey(t, kid)
+	r.serve = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r.hits.Add(1)
+		r.mu.Lock()
+		block := r.block
+		r.mu.Unlock()
+		if block != nil {
+			<-block
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(r.set)
+	}))
+	t.Cleanup(r.serve.Close)
+	return r
+}
+
+// setKey は配信する鍵集合を kid の新しい ES256 鍵 1 本に差し替える。
+func (r *rotatingJWKS) setKey(t *testing.T, kid string) {
+	t.Helper()
+	set := authtest.NewIssuer(t, jose.ES256, authtest.WithKeyID(kid)).JWKS(t)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set = set
+}
+
+// setFail は endpoint の障害状態を切り替える。
+func (r *rotatingJWKS) setFail(fail bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fail = fail
+}
+
+// setBlock は endpoint を応答しない状態にし、解除関数を返す。
+func (r *rotatingJWKS) setBlock(t *testing.T) func() {
+	t.Helper()
+	block := make(chan struct{})
+	r.mu.Lock()
+	r.block = block
+	r.mu.Unlock()
+	var once sync.Once
+	release := func() { once.Do(func() { close(block) }) }
+	// server の Close より先に応答を解放する。
+	t.Cleanup(release)
+	return release
+}
+
+// expire は c の鍵集合を期限切れにする。
+func expire(c *jwksCache) {
+	c.mu.Lock()
+	c.expiresAt = time.Now().Add(-time.Second)
+	c.mu.Unlock()
+}
+
+// waitRefresh は進行中の取得があれば完了まで待つ。
+func waitRefresh(c *jwksCache) {
+	c.mu.RLock()
+	done := c.refreshing
+	c.mu.RUnlock()
+	if done != nil {
+		<-done
+	}
+}
+
+func TestJWKSCache_ServesStaleOnFetchFailure(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL, JWKSCacheTTL: time.Minute})
+	if _, err := c.fetch(context.Background()); err != nil {
+		t.Fatalf("initial fetch: %v", err)
+	}
+	// 期限切れ + endpoint 障害でも last-good を返す。
+	r.setFail(true)
+	expire(c)
+	keys, err := c.fetch(context.Background())
+	if err != nil || len(keys.Key("k1")) != 1 {
+		t.Fatalf("stale keys should be served: keys=%v err=%v", keys, err)
+	}
+	waitRefresh(c)
+	// 再試行は抑制され、直後の fetch は endpoint を叩かない。
+	before := r.hits.Load()
+	if _, err := c.fetch(context.Background()); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if r.hits.Load() != before {
+		t.Fatalf("retry should be throttled after a failed refresh")
+	}
+}
+
+func TestJWKSCache_HungEndpointDoesNotBlockLookups(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL, JWKSCacheTTL: time.Minute})
+	c.refetchInterval = 0
+	c.fetchTimeout = 200 * time.Millisecond
+	if _, err := c.lookup(context.Background(), "k1"); err != nil {
+		t.Fatalf("initial lookup: %v", err)
+	}
+	release := r.setBlock(t)
+	defer release()
+	// 未知 kid の再取得が応答しない endpoint で止まっている間に期限切れにする。
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := c.lookup(context.Background(), "k2")
+		unknown <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for r.hits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	expire(c)
+	// 既知 kid の lookup は取得完了を待たず last-good で即答する。
+	start := time.Now()
+	matches, err := c.lookup(context.Background(), "k1")
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("stale k1 should be served: matches=%d err=%v", len(matches), err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("lookup blocked on a hung refresh for %v", elapsed)
+	}
+	// 再取得は fetchTimeout で打ち切られ、未知 kid の lookup も戻る。
+	select {
+	case err := <-unknown:
+		if err != nil {
+			t.Fatalf("unknown kid lookup should fall back to last-good: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("refresh was not bounded by fetchTimeout")
+	}
+}
+
+func TestJWKSCache_FailsWithoutLastGood(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	r.setFail(true)
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL})
+	if _, err := c.fetch(context.Background()); err == nil {
+		t.Fatalf("first fetch failure must be returned")
+	}
+}
+
+func TestJWKSCache_RefetchesOnUnknownKid(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL, JWKSCacheTTL: time.Hour})
+	c.refetchInterval = 0
+	if _, err := c.lookup(context.Background(), "k1"); err != nil {
+		t.Fatalf("lookup k1: %v", err)
+	}
+	// 鍵ローテーション: TTL 内でも新 kid は即時再取得で見つかる。
+	r.setKey(t, "k2")
+	matches, err := c.lookup(context.Background(), "k2")
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("rotated kid should be found: matches=%d err=%v", len(matches), err)
+	}
+	// 抑制間隔内の未知 kid は endpoint を叩かない。
+	c.refetchInterval = time.Hour
+	before := r.hits.Load()
+	if matches, _ := c.lookup(context.Background(), "bogus"); len(matches) != 0 {
+		t.Fatalf("bogus kid should not match")
+	}
+	if r.hits.Load() != before {
+		t.Fatalf("unknown kid refetch should be throttled")
+	}
+}
+
+func TestJWKSCache_UnknownKidWaitsForInflightRefresh(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL, JWKSCacheTTL: time.Minute})
+	if _, err := c.lookup(context.Background(), "k1"); err != nil {
+		t.Fatalf("initial lookup: %v", err)
+	}
+	// IdP が k2 にローテーションし、その取得が応答待ちの間に k2 の token が届く。
+	release := r.setBlock(t)
+	r.setKey(t, "k2")
+	expire(c)
+	if _, err := c.lookup(context.Background(), "k1"); err != nil {
+		t.Fatalf("stale lookup: %v", err)
+	}
+	found := make(chan int, 1)
+	go func() {
+		matches, err := c.lookup(context.Background(), "k2")
+		if err != nil {
+			t.Errorf("k2 lookup: %v", err)
+		}
+		found <- len(matches)
+	}()
+	// 抑制間隔内でも進行中の取得を待ち、その結果で k2 を見つける。
+	time.Sleep(50 * time.Millisecond)
+	release()
+	select {
+	case n := <-found:
+		if n != 1 {
+			t.Fatalf("k2 matches = %d, want 1 from the in-flight refresh", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("k2 lookup did not return")
+	}
+}
+
+func TestJWKSCache_BackgroundRefresh(t *testing.T) {
+	r := newRotatingJWKS(t, "k1")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newJWKSCache(Config{Mode: AuthModeJWKS, JWKSURL: r.serve.URL, JWKSCacheTTL: 20 * time.Millisecond, JWKSRefreshContext: ctx})
+	// 要求経路を一度も通らなくても先行更新で endpoint が叩かれる。
+	deadline := time.Now().Add(2 * time.Second)
+	for r.hits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if r.hits.Load() < 2 {
+		t.Fatalf("background refresh did not run, hits=%d", r.hits.Load())
+	}
+	c.mu.RLock()
+	loaded := c.jwks != nil
+	c.mu.RUnlock()
+	if !loaded {
+		t.Fatalf("background refresh should populate keys")
+	}
+	// ctx 終了後は更新が止まる。
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	stopped := r.hits.Load()
+	time.Sleep(100 * time.Millisecond)
+	if r.hits.Load() != stopped {
+		t.Fatalf("refresh loop should stop after ctx cancel")
+	}
+}
@@ -0,0 +1,134 @@
+// 本ファイルは tier2 共通 auth middleware の計測 hook。
+//
+// docs 正典:
+//   docs/05_実装/60_観測性設計/
+//
+// 役割:
+//   認証の健全性（失敗理由の内訳、JWKS 取得遅延、cache hit 率）を platform dashboard で
+//   サービスごとに追えるよう、計測値を Config.Metrics に通知する。
+//   tier2 Go module は Prometheus / OTel metric SDK に依存しないため、ここでは interface のみを
+//   定義し、各サービスが自身の registry（Counter / Histogram）に接続する adapter を実装する。
+//   失敗理由は label cardinality を抑えるため Reason* の固定集合に分類する（kid 等は含めない）。
+//
+// 推奨 metric:
+//   t2_auth_verify_total{mode,reason}            : ObserveVerify の件数（成功は reason=""）
+//   t2_auth_verify_duration_seconds{mode}        : ObserveVerify の所要時間
+//   t2_auth_jwks_fetch_duration_seconds{result}  : ObserveJWKSFetch
+//   t2_auth_jwks_cache_total{result=hit|miss}    : ObserveJWKSCache
+//   t2_auth_verify_cache_total{result=hit|miss}  : ObserveVerifyCache（Config.VerifyCache 設定時のみ）
+
+package auth
+
+// 標準 / 外部 import。
+import (
+	// 失敗理由の分類。
+	"errors"
+	// 所要時間。
+	"time"
+
+	// 標準クレーム検証の sentinel error。
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// 認証失敗理由（ObserveVerify の reason）。
+const (
+	// ReasonMissingToken は Authorization ヘッダ欠落 / 空 token。
+	ReasonMissingToken = "missing_token"
+	// ReasonMalformed は JWT として解析できない / 許可外 alg。
+	ReasonMalformed = "malformed"
+	// ReasonSignature は署名検証失敗。
+	ReasonSignature = "signature"
+	// ReasonExpired は exp / nbf / iat による期限外。
+	ReasonExpired = "expired"
+	// ReasonIssuer は未登録 / 不一致の iss。
+	ReasonIssuer = "issuer"
+	// ReasonUnknownKey は JWKS に kid が無い。
+	ReasonUnknownKey = "unknown_kid"
+	// ReasonKeyUnavailable は JWKS を取得できない。
+	ReasonKeyUnavailable = "key_unavailable"
+	// ReasonClaims は tenant_id / sub 欠落等の必須クレーム不備。
+	ReasonClaims = "claims"
+	// ReasonClaimsMapping は ClaimsMapper のエラー。
+	ReasonClaimsMapping = "claims_mapping"
+	// ReasonDPoP は DPoP proof 検証失敗。
+	ReasonDPoP = "dpop"
+	// ReasonMisconfigured は秘密鍵 / JWKS / alg の設定不備。
+	ReasonMisconfigured = "misconfigured"
+)
+
+// Metrics は認証の計測値を受け取る。実装は複数 goroutine から同時に呼ばれる。
+type Metrics interface {
+	// ObserveVerify は Required の判定 1 件（成功時 reason は空）と所要時間を通知する。
+	ObserveVerify(mode AuthMode, reason string, d time.Duration)
+	// ObserveJWKSFetch は JWKS endpoint への取得 1 回の所要時間と結果を通知する。
+	ObserveJWKSFetch(d time.Duration, err error)
+	// ObserveJWKSCache は鍵集合の参照が cache で解決したか（hit）を通知する。期限切れと未知 kid は miss。
+	ObserveJWKSCache(hit bool)
+	// ObserveVerifyCache は token の検証結果が検証結果キャッシュで解決したか（hit）を通知する。
+	ObserveVerifyCache(hit bool)
+}
+
+// noopMetrics は Config.Metrics 未設定時の既定実装。
+type noopMetrics struct{}
+
+// ObserveVerify は何もしない。
+func (noopMetrics) ObserveVerify(AuthMode, string, time.Duration) {}
+
+// ObserveJWKSFetch は何もしない。
+func (noopMetrics) ObserveJWKSFetch(time.Duration, error) {}
+
+// ObserveJWKSCache は何もしない。
+func (noopMetrics) ObserveJWKSCache(bool) {}
+
+// ObserveVerifyCache は何もしない。
+func (noopMetrics) ObserveVerifyCache(bool) {}
+
+// metricsOf は cfg.Metrics か no-op を返す。
+func metricsOf(cfg Config) Metrics {
+	// 未設定は no-op。
+	if cfg.Metrics == nil {
+		// no-op を返す。
+		return noopMetrics{}
+	}
+	// 注入された実装を返す。
+	return cfg.Metrics
+}
+
+// reasonError は失敗理由の分類を保持する error（メッセージは元の error のまま）。
+type reasonError struct {
+	reason string
+	err    error
+}
+
+// Error は元のメッセージを返す。
+func (e *reasonError) Error() string { return e.err.Error() }
+
+// Unwrap は元の error を返す。
+func (e *reasonError) Unwrap() error { return e.err }
+
+// fail は err に失敗理由 reason を付ける。
+func fail(reason string, err error) error {
+	// 分類付きで返す。
+	return &reasonError{reason: reason, err: err}
+}
+
+// failureReason は err を Reason* に分類する。分類の無い error は go-jose の sentinel から推定する。
+func failureReason(err error) string {
+	// 明示的な分類を優先する。
+	var re *reasonError
+	if errors.As(err, &re) {
+		return re.reason
+	}
+	// 標準クレーム / 署名の検証失敗。
+	switch {
+	case errors.Is(err, jwt.ErrExpired), errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+		return ReasonExpired
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return ReasonIssuer
+	case errors.Is(err, jose.ErrCryptoFailure):
+		return ReasonSignature
+	}
+	// 残りは必須クレーム不備。
+	return ReasonClaims
+}
@@ -0,0 +1,108 @@
+// 本ファイルは認証計測 hook の単体テスト。
+//
+// テスト観点:
+//   - 成功は reason="" 、失敗は Reason* の固定集合に分類して通知する
+//   - JWKS 取得は所要時間と結果を、鍵集合の参照は cache hit / miss を通知する
+
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// recordingMetrics は通知を記録する Metrics。
+type recordingMetrics struct {
+	mu      sync.Mutex
+	reasons []string
+	fetches int
+	hits    int
+	misses  int
+	// 検証結果キャッシュの hit / miss。
+	verifyHits   int
+	verifyMisses int
+}
+
+func (m *recordingMetrics) ObserveVerify(_ AuthMode, reason string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reasons = append(m.reasons, reason)
+}
+
+func (m *recordingMetrics) ObserveJWKSFetch(time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches++
+}
+
+func (m *recordingMetrics) ObserveJWKSCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func (m *recordingMetrics) ObserveVerifyCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.verifyHits++
+	} else {
+		m.verifyMisses++
+	}
+}
+
+func TestMetrics_VerifyReasons(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	m := &recordingMetrics{}
+	cfg := Config{Mode: AuthModeHMAC, HMACSecret: secret, Metrics: m}
+	cases := []struct {
+		token string
+		want  string
+	}{
+		{authtest.HMACToken(t, secret, authtest.Claims{}), ""},
+		{"", ReasonMissingToken},
+		{"not-a-jwt", ReasonMalformed},
+		{authtest.HMACToken(t, []byte("wrong-secret-32-bytes-long-enough"), authtest.Claims{}), ReasonSignature},
+		{authtest.HMACToken(t, secret, authtest.Claims{TTL: -time.Hour}), ReasonExpired},
+		{authtest.HMACToken(t, secret, authtest.Claims{Extra: map[string]any{"tenant_id": ""}}), ReasonClaims},
+	}
+	for _, c := range cases {
+		serve(RequiredWithConfig(cfg), nil, bearerRequest(c.token))
+	}
+	if len(m.reasons) != len(cases) {
+		t.Fatalf("observed %d, want %d", len(m.reasons), len(cases))
+	}
+	for i, c := range cases {
+		if m.reasons[i] != c.want {
+			t.Errorf("case %d: reason = %q, want %q", i, m.reasons[i], c.want)
+		}
+	}
+}
+
+func TestMetrics_JWKSFetchAndCache(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.ES256)
+	m := &recordingMetrics{}
+	mw := RequiredWithConfig(Config{Mode: AuthModeJWKS, JWKSURL: iss.ServeJWKS(t), Metrics: m})
+	tok := iss.Token(t, authtest.Claims{})
+	for range 3 {
+		serve(mw, nil, bearerRequest(tok))
+	}
+	if m.fetches != 1 || m.misses != 1 || m.hits != 2 {
+		t.Fatalf("fetches=%d misses=%d hits=%d, want 1/1/2", m.fetches, m.misses, m.hits)
+	}
+	// JWKS に無い kid（ES384 発行者）は unknown_kid に分類され cache miss として計測される
+	// （抑制間隔内のため再取得しない）。
+	serve(mw, nil, bearerRequest(authtest.NewIssuer(t, jose.ES384).Token(t, authtest.Claims{})))
+	if got := m.reasons[len(m.reasons)-1]; got != ReasonUnknownKey || m.fetches != 1 || m.misses != 2 {
+		t.Fatalf("reason=%q fetches=%d misses=%d", got, m.fetches, m.misses)
+	}
+}
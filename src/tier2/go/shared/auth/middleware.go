@@ -11,7 +11,29 @@
 //   T2_AUTH_MODE 環境変数の値に応じて 3 通り検証する:
 //     - off  : dev 限定。署名検証 skip、subject="dev" / tenant_id="demo-tenant" を context に積む
 //     - hmac : T2_AUTH_HMAC_SECRET の HS256/384/512 で署名 + 期限 + テナント claim を検証
-//     - jwks : T2_AUTH_JWKS_URL から JWKS を fetch しキャッシュ、RS256/384/512 で検証
+//     - jwks : T2_AUTH_JWKS_URL から JWKS を fetch しキャッシュ、RS / PS / ES / EdDSA で検証
+//
+//   JWKS の取得・更新（stale-while-revalidate / 未知 kid の再取得 / 先行更新）は jwks.go、
+//   T2_AUTH_* 環境変数からの Config 読込（LoadConfigFromEnv）は env.go に分離する。
+//
+//   air-gapped 環境 / test では T2_AUTH_JWKS_FILE（または Config.JWKSJSON）で JWKS を
+//   ローカルから与えられる。その場合は URL fetch を行わず、同じ検証経路で claims を取り出す。
+//
+//   複数 realm / IdP の token を受ける場合は T2_AUTH_ISSUERS（iss=url をカンマ区切り）で
+//   issuer ごとの JWKS を与える。iss で鍵集合を選び、検証後に iss を再照合する（issuers.go）。
+//
+//   非標準クレームは Config.ClaimsMapper で型付き struct に写像し context に載せられる（claims.go）。
+//
+//   Config.AuditHook を設定すると 401 と後段 guard の 403 を理由付きで通知する（audit.go）。
+//
+//   Config.DPoP で DPoP（RFC 9449）proof による token の鍵束縛を opt-in で要求できる（dpop.go）。
+//
+//   Config.VerifyCache で同一 token の署名検証結果を短時間 cache できる（verifycache.go）。
+//
+//   Config.Metrics で検証結果（失敗理由別）/ JWKS 取得遅延 / cache hit を計測できる（metrics.go）。
+//
+//   受け付ける署名アルゴリズムは T2_AUTH_ALLOWED_ALGS（カンマ区切り）で絞り込める。
+//   mode と鍵種別が整合しないアルゴリズム（jwks での HS* 等）は allow-list に書いても拒否する。
 //
 //   検証成功時は subject / tenant_id / 生 token を request context に attach し、
 //   後段の handler / k1s0 SDK 呼出で取り出して TenantContext に詰めて tier1 へ送る。
@@ -35,12 +57,8 @@ import (
 	"fmt"
 	// HTTP server。
 	"net/http"
-	// 環境変数読込。
-	"os"
 	// 文字列処理。
 	"strings"
-	// 排他制御（JWKS cache）。
-	"sync"
 	// 期限処理。
 	"time"
 
@@ -64,6 +82,12 @@ const TokenKey contextKey = "k1s0.bearer_token"
 // RolesKey は認証済 Realm Role 一覧を context から取り出すキー（NFR-E-AC-002 RBAC）。
 const RolesKey contextKey = "k1s0.roles"
 
+// ScopesKey は認証済 OAuth scope 一覧を context から取り出すキー。
+const ScopesKey contextKey = "k1s0.scopes"
+
+// IssuerKey は認証済 token の iss を context から取り出すキー。
+const IssuerKey contextKey = "k1s0.issuer"
+
 // AuthMode は middleware の動作モード。
 type AuthMode string
 
@@ -72,10 +96,22 @@ const (
 	AuthModeOff AuthMode = "off"
 	// AuthModeHMAC は HS256 共有秘密鍵で検証（CI / dev）。
 	AuthModeHMAC AuthMode = "hmac"
-	// AuthModeJWKS は JWKS URL から取得した RSA 公開鍵で RS256 検証（production / Keycloak）。
+	// AuthModeJWKS は JWKS URL から取得した公開鍵で検証（production / Keycloak）。
 	AuthModeJWKS AuthMode = "jwks"
 )
 
+// hmacAlgorithms は mode=hmac で受け付け得るアルゴリズム。
+var hmacAlgorithms = []jose.SignatureAlgorithm{jose.HS256, jose.HS384, jose.HS512}
+
+// jwksAlgorithms は mode=jwks で受け付け得るアルゴリズム（公開鍵系のみ）。
+// HS* を含めると公開鍵を HMAC 秘密鍵として扱わせる alg 混同攻撃を許すため除外する。
+var jwksAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
 // authClaims は JWT から取り出すクレーム（tenant_id 必須、Keycloak 互換）。
 // realm_access.roles を解釈して RolesKey に attach する（NFR-E-AC-002 RBAC）。
 type authClaims struct {
@@ -85,6 +121,12 @@ type authClaims struct {
 	RealmAccess *struct {
 		Roles []string `json:"roles"`
 	} `json:"realm_access,omitempty"`
+	// OAuth 2.0 の scope クレーム（空白区切り）。
+	Scope string `json:"scope,omitempty"`
+	// DPoP 束縛先の鍵 thumbprint（RFC 9449 cnf.jkt）。
+	Cnf *struct {
+		JKT string `json:"jkt"`
+	} `json:"cnf,omitempty"`
 	// JWT 標準クレーム（exp / iat / nbf / sub）。
 	jwt.Claims
 }
@@ -143,109 +185,116 @@ type Config struct {
 	HMACSecret []byte
 	// JWKS endpoint URL（mode=jwks のみ使用）。
 	JWKSURL string
+	// ローカル JWKS の JSON（mode=jwks のみ使用）。設定時は JWKSURL より優先し fetch しない。
+	JWKSJSON []byte
 	// JWKS cache TTL。0 で 10 分既定。
 	JWKSCacheTTL time.Duration
+	// multi-issuer 構成の iss → JWKS URL（mode=jwks のみ使用）。設定時は JWKSURL / JWKSJSON より優先する。
+	Issuers map[string]string
+	// 設定時は ctx 終了まで TTL 満了前に jitter 付きで JWKS を先行更新する（nil で要求経路のみ更新）。
+	JWKSRefreshContext context.Context
 	// HTTP client（test 注入可能）。
 	HTTPClient *http.Client
+	// 受け付ける署名アルゴリズムの allow-list。空なら mode の既定集合を全て許可する。
+	AllowedAlgorithms []jose.SignatureAlgorithm
+	// 検証済クレームをアプリ固有の拡張 struct に写像する hook（nil で無効）。claims.go 参照。
+	ClaimsMapper ClaimsMapper
+	// 401 / 403 の判定を通知する hook（nil で無効）。audit.go 参照。
+	AuditHook AuditHook
+	// DPoP proof 検証（nil で無効、Bearer のみ受け付ける）。dpop.go 参照。
+	DPoP *DPoPConfig
+	// 検証結果 / JWKS 取得の計測先（nil で no-op）。metrics.go 参照。
+	Metrics Metrics
+	// 検証結果キャッシュ（nil で無効、毎回検証する）。verifycache.go 参照。
+	VerifyCache *VerifyCacheConfig
+	// T2_AUTH_JWKS_FILE の読込失敗（LoadConfigFromEnv のみ設定、mode=jwks の全要求を拒否する）。
+	jwksFileErr error
 }
 
-// LoadConfigFromEnv は環境変数から Config を構築する。
-//
-// 既定 Mode は off（dev 既定）。production では T2_AUTH_MODE=jwks を必ず設定する。
-func LoadConfigFromEnv() Config {
-	mode := AuthMode(os.Getenv("T2_AUTH_MODE"))
-	if mode == "" {
-		mode = AuthModeOff
-	}
-	return Config{
-		Mode:         mode,
-		HMACSecret:   []byte(os.Getenv("T2_AUTH_HMAC_SECRET")),
-		JWKSURL:      os.Getenv("T2_AUTH_JWKS_URL"),
-		JWKSCacheTTL: 10 * time.Minute,
-		HTTPClient:   http.DefaultClient,
-	}
-}
-
-// jwksCache は JWKS の TTL 付き cache（複数 goroutine 安全）。
-type jwksCache struct {
-	mu        sync.RWMutex
-	jwks      *jose.JSONWebKeySet
-	expiresAt time.Time
-	url       string
-	ttl       time.Duration
-	client    *http.Client
-}
-
-// fetch は JWKS を URL から取得する（cache miss / expire 時のみ）。
-func (c *jwksCache) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
-	c.mu.RLock()
-	if c.jwks != nil && time.Now().Before(c.expiresAt) {
-		j := c.jwks
-		c.mu.RUnlock()
-		return j, nil
-	}
-	c.mu.RUnlock()
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.jwks != nil && time.Now().Before(c.expiresAt) {
-		return c.jwks, nil
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("jwks fetch: %w", err)
+// algorithmsFor は mode の既定集合と allow-list の積集合を返す。
+// allow-list が空なら既定集合をそのまま返す。
+func (c Config) algorithmsFor(base []jose.SignatureAlgorithm) []jose.SignatureAlgorithm {
+	// allow-list 未指定は既定集合。
+	if len(c.AllowedAlgorithms) == 0 {
+		// 既定集合を返す。
+		return base
 	}
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("jwks fetch: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("jwks fetch: HTTP %d", resp.StatusCode)
-	}
-	var keys jose.JSONWebKeySet
-	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
-		return nil, fmt.Errorf("jwks decode: %w", err)
+	// 既定集合に含まれるものだけ残す（mode 不整合や "none" は落ちる）。
+	out := make([]jose.SignatureAlgorithm, 0, len(base))
+	for _, alg := range base {
+		for _, allowed := range c.AllowedAlgorithms {
+			// 完全一致のみ。
+			if alg == allowed {
+				out = append(out, alg)
+				break
+			}
+		}
 	}
-	c.jwks = &keys
-	c.expiresAt = time.Now().Add(c.ttl)
-	return c.jwks, nil
+	// 積集合を返す。
+	return out
 }
 
 // RequiredWithConfig は cfg を使う Required 内部実装。test で cfg を差し替えるために分離する。
 func RequiredWithConfig(cfg Config) func(http.Handler) http.Handler {
-	var jwks *jwksCache
-	if cfg.Mode == AuthModeJWKS && cfg.JWKSURL != "" {
-		ttl := cfg.JWKSCacheTTL
-		if ttl <= 0 {
-			ttl = 10 * time.Minute
-		}
-		client := cfg.HTTPClient
-		if client == nil {
-			client = http.DefaultClient
-		}
-		jwks = &jwksCache{url: cfg.JWKSURL, ttl: ttl, client: client}
+	jwks := newJWKSResolver(cfg)
+	dpop := newDPoPVerifier(cfg)
+	metrics := metricsOf(cfg)
+	verified := newVerifyCache(cfg)
+	// DPoP 構成では束縛 token を DPoP scheme で受ける（RFC 9449 §7.1）。
+	scheme := "Bearer "
+	if dpop != nil {
+		scheme = "DPoP "
 	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if !strings.HasPrefix(authHeader, "Bearer ") {
-				writeUnauthorized(w, "missing bearer token")
+			// 401 は計測と audit hook に通知してから返す（ctx に識別情報があれば hook に載る）。
+			start := time.Now()
+			reject := func(ctx context.Context, reason, msg string) {
+				metrics.ObserveVerify(cfg.Mode, reason, time.Since(start))
+				auditDenied(ctx, cfg.AuditHook, r, http.StatusUnauthorized, msg)
+				writeUnauthorized(w, msg)
+			}
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), scheme)
+			if !ok {
+				reject(r.Context(), ReasonMissingToken, "missing "+strings.ToLower(strings.TrimSpace(scheme))+" token")
 				return
 			}
-			token := strings.TrimPrefix(authHeader, "Bearer ")
 			if strings.TrimSpace(token) == "" {
-				writeUnauthorized(w, "empty token")
+				reject(r.Context(), ReasonMissingToken, "empty token")
 				return
 			}
-			subject, tenantID, roles, err := authenticate(r.Context(), cfg, jwks, token)
+			id, err := verified.verify(token, func() (*identity, error) { return authenticate(r.Context(), cfg, jwks, token) })
 			if err != nil {
-				writeUnauthorized(w, err.Error())
+				reject(r.Context(), failureReason(err), err.Error())
 				return
 			}
-			ctx := context.WithValue(r.Context(), SubjectKey, subject)
-			ctx = context.WithValue(ctx, TenantIDKey, tenantID)
+			if dpop != nil {
+				if err := dpop.verify(r, token, id.jkt); err != nil {
+					w.Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+					reject(r.Context(), ReasonDPoP, err.Error())
+					return
+				}
+			}
+			ctx := context.WithValue(r.Context(), SubjectKey, id.subject)
+			ctx = context.WithValue(ctx, TenantIDKey, id.tenantID)
 			ctx = context.WithValue(ctx, TokenKey, token)
-			ctx = context.WithValue(ctx, RolesKey, roles)
+			ctx = context.WithValue(ctx, RolesKey, id.roles)
+			ctx = context.WithValue(ctx, ScopesKey, id.scopes)
+			ctx = context.WithValue(ctx, IssuerKey, id.issuer)
+			// 後段の RequireAnyRole 等が 403 を hook に通知できるよう context に載せる。
+			if cfg.AuditHook != nil {
+				ctx = context.WithValue(ctx, auditHookKey, cfg.AuditHook)
+			}
+			// アプリ固有クレームの写像は標準 claims の検証後に行う。
+			ext, mapped, err := mapClaims(cfg, id)
+			if err != nil {
+				reject(ctx, ReasonClaimsMapping, err.Error())
+				return
+			}
+			if mapped {
+				ctx = context.WithValue(ctx, ExtensionKey, ext)
+			}
+			metrics.ObserveVerify(cfg.Mode, "", time.Since(start))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -256,68 +305,113 @@ func Required() func(http.Handler) http.Handler {
 	return RequiredWithConfig(LoadConfigFromEnv())
 }
 
-// authenticate は token を mode に応じて検証し、subject / tenant_id / roles を返す。
+// identity は検証済 token から取り出した識別情報。
+type identity struct {
+	// sub クレーム。
+	subject string
+	// tenant_id クレーム。
+	tenantID string
+	// realm_access.roles を平坦化したもの。
+	roles []string
+	// scope クレーム（空白区切り）を分解したもの。
+	scopes []string
+	// iss クレーム（multi-issuer 構成ではどの realm / IdP の token かを示す）。
+	issuer string
+	// 検証済 payload の全クレーム（ClaimsMapper 用。off mode では nil）。
+	raw map[string]any
+	// DPoP 束縛先の鍵 thumbprint（cnf.jkt、無ければ空）。
+	jkt string
+	// exp クレーム（無ければゼロ値）。検証結果キャッシュの有効期間の上限に使う。
+	expiry time.Time
+}
+
+// authenticate は token を mode に応じて検証し、subject / tenant_id / roles / scopes を返す。
 // roles は Keycloak realm_access.roles を平坦化したもの（NFR-E-AC-002 RBAC）。
-func authenticate(ctx context.Context, cfg Config, jwks *jwksCache, token string) (string, string, []string, error) {
+func authenticate(ctx context.Context, cfg Config, jwks *jwksResolver, token string) (*identity, error) {
 	switch cfg.Mode {
 	case AuthModeOff:
 		// dev 既定: token 内容を見ず demo-tenant に固定する（tier3 BFF off mode と同等）。
-		// off モードでは roles は空（RBAC は dev でスキップ）。
-		return "dev", "demo-tenant", nil, nil
+		// off モードでは roles / scopes は空（RequireAnyRole 等の RBAC guard は 403 になる）。
+		return &identity{subject: "dev", tenantID: "demo-tenant"}, nil
 	case AuthModeHMAC:
 		if len(cfg.HMACSecret) == 0 {
-			return "", "", nil, errors.New("T2_AUTH_HMAC_SECRET not set")
+			return nil, fail(ReasonMisconfigured, errors.New("T2_AUTH_HMAC_SECRET not set"))
+		}
+		algs := cfg.algorithmsFor(hmacAlgorithms)
+		if len(algs) == 0 {
+			return nil, fail(ReasonMisconfigured, errors.New("no allowed algorithms for hmac mode"))
 		}
-		parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.HS256, jose.HS384, jose.HS512})
+		parsed, err := jwt.ParseSigned(token, algs)
 		if err != nil {
-			return "", "", nil, fmt.Errorf("parse: %w", err)
+			return nil, fail(ReasonMalformed, fmt.Errorf("parse: %w", err))
 		}
 		var claims authClaims
-		if err := parsed.Claims(cfg.HMACSecret, &claims); err != nil {
-			return "", "", nil, fmt.Errorf("verify: %w", err)
+		var raw map[string]any
+		if err := parsed.Claims(cfg.HMACSecret, &claims, &raw); err != nil {
+			return nil, fail(ReasonSignature, fmt.Errorf("verify: %w", err))
 		}
-		return finalizeClaims(&claims)
+		return finalizeClaims(&claims, raw, "")
 	case AuthModeJWKS:
 		if jwks == nil {
-			return "", "", nil, errors.New("jwks not configured")
+			return nil, fail(ReasonMisconfigured, errors.New("jwks not configured"))
 		}
-		keys, err := jwks.fetch(ctx)
-		if err != nil {
-			return "", "", nil, err
+		algs := cfg.algorithmsFor(jwksAlgorithms)
+		if len(algs) == 0 {
+			return nil, fail(ReasonMisconfigured, errors.New("no allowed algorithms for jwks mode"))
 		}
-		parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.RS256, jose.RS384, jose.RS512})
+		parsed, err := jwt.ParseSigned(token, algs)
 		if err != nil {
-			return "", "", nil, fmt.Errorf("parse: %w", err)
+			return nil, fail(ReasonMalformed, fmt.Errorf("parse: %w", err))
 		}
 		if len(parsed.Headers) == 0 {
-			return "", "", nil, errors.New("jwt has no header")
+			return nil, fail(ReasonMalformed, errors.New("jwt has no header"))
+		}
+		// multi-issuer 構成では未検証の iss で鍵集合を選び、検証後に iss を再照合する。
+		cache, expectedIssuer, err := jwks.resolve(parsed)
+		if err != nil {
+			return nil, err
+		}
+		// 未知 kid は鍵ローテーション直後の可能性があるため lookup 内で再取得を試みる。
+		matches, err := cache.lookup(ctx, parsed.Headers[0].KeyID)
+		if err != nil {
+			return nil, fail(ReasonKeyUnavailable, err)
 		}
-		matches := keys.Key(parsed.Headers[0].KeyID)
 		if len(matches) == 0 {
-			return "", "", nil, fmt.Errorf("kid %q not found in jwks", parsed.Headers[0].KeyID)
+			return nil, fail(ReasonUnknownKey, fmt.Errorf("kid %q not found in jwks", parsed.Headers[0].KeyID))
 		}
 		var claims authClaims
-		if err := parsed.Claims(matches[0].Key, &claims); err != nil {
-			return "", "", nil, fmt.Errorf("verify: %w", err)
+		var raw map[string]any
+		if err := parsed.Claims(matches[0].Key, &claims, &raw); err != nil {
+			return nil, fail(ReasonSignature, fmt.Errorf("verify: %w", err))
 		}
-		return finalizeClaims(&claims)
+		return finalizeClaims(&claims, raw, expectedIssuer)
 	default:
-		return "", "", nil, fmt.Errorf("unsupported T2_AUTH_MODE: %s", cfg.Mode)
+		return nil, fail(ReasonMisconfigured, fmt.Errorf("unsupported T2_AUTH_MODE: %s", cfg.Mode))
 	}
 }
 
-// finalizeClaims は標準クレームを検証し、必須フィールドと roles を返す。
-func finalizeClaims(claims *authClaims) (string, string, []string, error) {
-	if err := claims.Claims.ValidateWithLeeway(jwt.Expected{Time: time.Now()}, 30*time.Second); err != nil {
-		return "", "", nil, fmt.Errorf("standard claims: %w", err)
+// finalizeClaims は標準クレームを検証し、必須フィールドと roles / scopes を返す。
+// issuer が空でなければ iss クレームの一致も検証する。raw は ClaimsMapper に渡す全クレーム。
+func finalizeClaims(claims *authClaims, raw map[string]any, issuer string) (*identity, error) {
+	if err := claims.Claims.ValidateWithLeeway(jwt.Expected{Time: time.Now(), Issuer: issuer}, 30*time.Second); err != nil {
+		return nil, fmt.Errorf("standard claims: %w", err)
 	}
 	if claims.TenantID == "" {
-		return "", "", nil, errors.New("missing tenant_id claim")
+		return nil, errors.New("missing tenant_id claim")
 	}
 	if claims.Subject == "" {
-		return "", "", nil, errors.New("missing sub claim")
+		return nil, errors.New("missing sub claim")
 	}
-	return claims.Subject, claims.TenantID, claims.flattenedRoles(), nil
+	return &identity{
+		subject:  claims.Subject,
+		tenantID: claims.TenantID,
+		roles:    claims.flattenedRoles(),
+		scopes:   strings.Fields(claims.Scope),
+		issuer:   claims.Issuer,
+		raw:      raw,
+		jkt:      claims.confirmationKey(),
+		expiry:   claims.Expiry.Time(),
+	}, nil
 }
 
 // SubjectFromContext は middleware が attach した subject を取り出す。
@@ -7,18 +7,24 @@
 //   - off mode: token 内容を見ず demo-tenant を context に積む
 //   - hmac mode: 有効な HS256 token は通過、無効な署名は 401、tenant_id 欠如は 401
 //   - missing/empty Authorization は 401
+//   - jwks mode: ES256 / EdDSA 鍵を受け付け、allow-list 外 / HS* の alg 混同は 401
+//   - ローカル JWKS: URL fetch 無しで検証し、不正 JSON は fail closed
 
 package auth
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/go-jose/go-jose/v4"
-	"github.com/go-jose/go-jose/v4/jwt"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
 )
 
 // passthroughHandler は middleware のテストで「通過したら何が context に入っているか」を
@@ -87,28 +93,7 @@ func TestRejects_NonBearerScheme(t *testing.T) {
 
 func TestHmacMode_AcceptsValidToken(t *testing.T) {
 	secret := []byte("test-secret-32bytes-long-aaaaaaaa")
-	signer, err := jose.NewSigner(
-		jose.SigningKey{Algorithm: jose.HS256, Key: secret},
-		(&jose.SignerOptions{}).WithType("JWT"),
-	)
-	if err != nil {
-		t.Fatalf("signer: %v", err)
-	}
-	claims := struct {
-		TenantID string `json:"tenant_id"`
-		jwt.Claims
-	}{
-		TenantID: "T-PROD",
-		Claims: jwt.Claims{
-			Subject:  "alice",
-			Expiry:   jwt.NewNumericDate(time.Now().Add(60 * time.Second)),
-			IssuedAt: jwt.NewNumericDate(time.Now()),
-		},
-	}
-	tok, err := jwt.Signed(signer).Claims(claims).Serialize()
-	if err != nil {
-		t.Fatalf("sign: %v", err)
-	}
+	tok := authtest.HMACToken(t, secret, authtest.Claims{Subject: "alice", TenantID: "T-PROD", TTL: 60 * time.Second})
 	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret})
 	req := httptest.NewRequest(http.MethodGet, "/x", nil).WithContext(context.Background())
 	req.Header.Set("Authorization", "Bearer "+tok)
@@ -128,16 +113,7 @@ func TestHmacMode_AcceptsValidToken(t *testing.T) {
 func TestHmacMode_RejectsInvalidSignature(t *testing.T) {
 	correct := []byte("correct-secret-32bytes-long-aaaaa")
 	wrong := []byte("wrong-secret-32bytes-long-aaaaaaaa")
-	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: wrong},
-		(&jose.SignerOptions{}).WithType("JWT"))
-	claims := struct {
-		TenantID string `json:"tenant_id"`
-		jwt.Claims
-	}{TenantID: "T1", Claims: jwt.Claims{
-		Subject: "bob",
-		Expiry:  jwt.NewNumericDate(time.Now().Add(60 * time.Second)),
-	}}
-	tok, _ := jwt.Signed(signer).Claims(claims).Serialize()
+	tok := authtest.HMACToken(t, wrong, authtest.Claims{Subject: "bob", TenantID: "T1", TTL: 60 * time.Second})
 	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: correct})
 	req := httptest.NewRequest(http.MethodGet, "/x", nil)
 	req.Header.Set("Authorization", "Bearer "+tok)
@@ -150,16 +126,8 @@ func TestHmacMode_RejectsInvalidSignature(t *testing.T) {
 
 func TestHmacMode_RejectsMissingTenantClaim(t *testing.T) {
 	secret := []byte("test-secret-32bytes-long-aaaaaaaa")
-	signer, _ := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: secret},
-		(&jose.SignerOptions{}).WithType("JWT"))
 	// tenant_id 欠落の token を作る。
-	claims := struct {
-		jwt.Claims
-	}{Claims: jwt.Claims{
-		Subject: "carol",
-		Expiry:  jwt.NewNumericDate(time.Now().Add(60 * time.Second)),
-	}}
-	tok, _ := jwt.Signed(signer).Claims(claims).Serialize()
+	tok := authtest.HMACToken(t, secret, authtest.Claims{Subject: "carol", TTL: 60 * time.Second, Extra: map[string]any{"tenant_id": nil}})
 	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret})
 	req := httptest.NewRequest(http.MethodGet, "/x", nil)
 	req.Header.Set("Authorization", "Bearer "+tok)
@@ -169,3 +137,108 @@ func TestHmacMode_RejectsMissingTenantClaim(t *testing.T) {
 		t.Fatalf("missing tenant_id should be 401; got %d", rec.Code)
 	}
 }
+
+// serve は mw の後段を next（nil なら passthroughHandler）にして req を流した応答を返す。
+// package 内の middleware / guard の test はこの harness を共有する。
+func serve(mw func(http.Handler) http.Handler, next http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	if next == nil {
+		next = passthroughHandler
+	}
+	rec := httptest.NewRecorder()
+	mw(next).ServeHTTP(rec, req)
+	return rec
+}
+
+// bearerRequest は Authorization: Bearer token 付きの GET /x を返す。
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestJWKSMode_AcceptsES256AndEdDSA(t *testing.T) {
+	for _, alg := range []jose.SignatureAlgorithm{jose.ES256, jose.EdDSA} {
+		iss := authtest.NewIssuer(t, alg)
+		cfg := Config{Mode: AuthModeJWKS, JWKSURL: iss.ServeJWKS(t)}
+		if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(iss.Token(t, authtest.Claims{}))).Code; got != http.StatusOK {
+			t.Errorf("%s token should be accepted; got %d", alg, got)
+		}
+	}
+}
+
+func TestJWKSMode_AllowListRejectsOtherAlgorithms(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.RS256)
+	url := iss.ServeJWKS(t)
+	token := iss.Token(t, authtest.Claims{})
+	// 既定集合では RS256 は通る。
+	if got := serve(RequiredWithConfig(Config{Mode: AuthModeJWKS, JWKSURL: url}), nil, bearerRequest(token)).Code; got != http.StatusOK {
+		t.Fatalf("RS256 should be accepted by default; got %d", got)
+	}
+	// ES256 のみ許可すると RS256 は拒否される。
+	cfg := Config{Mode: AuthModeJWKS, JWKSURL: url, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.ES256}}
+	if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(token)).Code; got != http.StatusUnauthorized {
+		t.Fatalf("RS256 outside allow-list should be 401; got %d", got)
+	}
+	// jwks mode で HS256 を allow-list に書いても公開鍵系以外は積集合から落ちる。
+	cfg.AllowedAlgorithms = []jose.SignatureAlgorithm{jose.HS256, "none"}
+	if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(token)).Code; got != http.StatusUnauthorized {
+		t.Fatalf("empty effective allow-list should be 401; got %d", got)
+	}
+}
+
+func TestParseAlgorithms(t *testing.T) {
+	got := parseAlgorithms(" RS256, ,ES256 ")
+	if len(got) != 2 || got[0] != jose.RS256 || got[1] != jose.ES256 {
+		t.Fatalf("parseAlgorithms = %v", got)
+	}
+	if parseAlgorithms("") != nil {
+		t.Fatalf("empty should be nil")
+	}
+}
+
+func TestJWKSMode_StaticJWKS(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.ES256)
+	token := iss.Token(t, authtest.Claims{})
+	// URL は到達不能でもローカル JWKS が優先される。
+	cfg := Config{Mode: AuthModeJWKS, JWKSURL: "http://127.0.0.1:0/unreachable", JWKSJSON: iss.JWKS(t)}
+	if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(token)).Code; got != http.StatusOK {
+		t.Fatalf("static JWKS should verify; got %d", got)
+	}
+	// 不正 JSON は全要求 401。
+	cfg.JWKSJSON = []byte("not-json")
+	if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(token)).Code; got != http.StatusUnauthorized {
+		t.Fatalf("invalid static JWKS should fail closed; got %d", got)
+	}
+}
+
+func TestReadJWKSFile(t *testing.T) {
+	if b, err := readJWKSFile(""); b != nil || err != nil {
+		t.Fatalf("empty path should be nil, nil; got %q, %v", b, err)
+	}
+	path := t.TempDir() + "/jwks.json"
+	if err := os.WriteFile(path, []byte(`{"keys":[]}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got, err := readJWKSFile(path); err != nil || string(got) != `{"keys":[]}` {
+		t.Fatalf("readJWKSFile = %q, %v", got, err)
+	}
+	// 読込失敗は os の error を wrap して返す。
+	if _, err := readJWKSFile(path + ".missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestLoadConfigFromEnv_UnreadableJWKSFileFailsClosed(t *testing.T) {
+	iss := authtest.NewIssuer(t, jose.ES256)
+	t.Setenv("T2_AUTH_MODE", "jwks")
+	t.Setenv("T2_AUTH_JWKS_URL", iss.ServeJWKS(t))
+	t.Setenv("T2_AUTH_JWKS_FILE", t.TempDir()+"/missing.json")
+	cfg := LoadConfigFromEnv()
+	if !errors.Is(cfg.jwksFileErr, fs.ErrNotExist) {
+		t.Fatalf("jwksFileErr = %v", cfg.jwksFileErr)
+	}
+	// 読めない JWKS ファイルは URL に fallback せず 401。
+	if got := serve(RequiredWithConfig(cfg), nil, bearerRequest(iss.Token(t, authtest.Claims{}))).Code; got != http.StatusUnauthorized {
+		t.Fatalf("unreadable JWKS file should fail closed; got %d", got)
+	}
+}
@@ -0,0 +1,119 @@
+// 本ファイルは tier2 共通 auth middleware の permission matrix（RBAC）。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-002（RBAC）
+//
+// 役割:
+//   role → resource → actions の対応表を設定ファイル（JSON）から読み、コードに role 名を
+//   直書きせずに「誰が何をできるか」を運用側で変更できるようにする。YAML / config server は
+//   未対応（tier2 go module が YAML 依存を持たないため）。YAML で管理する場合は配備時に JSON へ変換する。
+//     - "*" は resource / action のワイルドカード
+//     - matrix 未設定（nil）の場合は従来挙動に fallback し、admin role のみ全操作を許可する
+//       （設定ファイルの JSON null は未設定と区別できないため読込時に拒否する）
+//   RequirePermission は roles.go の guard と同じく不足時に 403（E-T2-AUTH-002）を返す。
+//
+// 設定例（JSON）:
+//   {"operator": {"stock": ["read", "reconcile"]}, "auditor": {"*": ["read"]}}
+//
+// 利用例:
+//   perms, err := t2auth.LoadPermissionMatrix(os.Getenv("T2_AUTH_PERMISSIONS_FILE"))
+//   mux.Handle("/reconcile", t2auth.Required()(t2auth.RequirePermission(perms, "stock", "reconcile")(h)))
+
+package auth
+
+// 標準 import。
+import (
+	// context 伝搬。
+	"context"
+	// JSON decode。
+	"encoding/json"
+	// エラー生成。
+	"errors"
+	// エラー文字列整形。
+	"fmt"
+	// HTTP server。
+	"net/http"
+	// 設定ファイル読込。
+	"os"
+)
+
+// fallbackAdminRole は matrix 未設定時に全操作を許可する role。
+const fallbackAdminRole = "admin"
+
+// permissionWildcard は resource / action の任意一致。
+const permissionWildcard = "*"
+
+// PermissionMatrix は role → resource → 許可 action の対応表。
+type PermissionMatrix map[string]map[string][]string
+
+// ParsePermissionMatrix は JSON の permission matrix を decode する。
+// JSON null は nil matrix（admin fallback）に化けるため拒否する（全拒否は {} で表す）。
+func ParsePermissionMatrix(raw []byte) (PermissionMatrix, error) {
+	// decode する。
+	var m PermissionMatrix
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("permission matrix decode: %w", err)
+	}
+	// 設定ファイルを与えた上での null は設定誤りとして扱う。
+	if m == nil {
+		return nil, errors.New("permission matrix decode: matrix is null (use {} to deny all)")
+	}
+	// decode 結果を返す。
+	return m, nil
+}
+
+// LoadPermissionMatrix は path の JSON を読む。path が空なら nil（admin fallback）を返す。
+func LoadPermissionMatrix(path string) (PermissionMatrix, error) {
+	// 未設定は fallback。
+	if path == "" {
+		// nil を返す。
+		return nil, nil
+	}
+	// ファイルを読む。
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("permission matrix read: %w", err)
+	}
+	// decode 結果を返す。
+	return ParsePermissionMatrix(raw)
+}
+
+// Allows は roles のいずれかが resource に対する action を許可されているかを返す。
+// nil matrix は admin role のみ全許可とする。
+func (m PermissionMatrix) Allows(roles []string, resource, action string) bool {
+	// 未設定は従来挙動。
+	if m == nil {
+		// admin のみ許可。
+		return containsString(roles, fallbackAdminRole)
+	}
+	// role ごとに resource の完全一致とワイルドカードを調べる。
+	for _, role := range roles {
+		resources := m[role]
+		for _, res := range []string{resource, permissionWildcard} {
+			actions := resources[res]
+			if containsString(actions, action) || containsString(actions, permissionWildcard) {
+				return true
+			}
+		}
+	}
+	// 不許可。
+	return false
+}
+
+// HasPermission は context 内 roles が m 上で resource への action を許可されているかを判定する。
+func HasPermission(ctx context.Context, m PermissionMatrix, resource, action string) bool {
+	// matrix に問い合わせる。
+	return m.Allows(RolesFromContext(ctx), resource, action)
+}
+
+// RequirePermission は m 上で resource への action を許可された要求のみ通す middleware を返す。
+func RequirePermission(m PermissionMatrix, resource, action string) func(http.Handler) http.Handler {
+	return guard(func(ctx context.Context) (bool, string) {
+		// 許可されていれば通過。
+		if HasPermission(ctx, m, resource, action) {
+			return true, ""
+		}
+		// 不足内容を返す。
+		return false, "missing permission: " + action + " on " + resource
+	})
+}
@@ -0,0 +1,96 @@
+// 本ファイルは permission matrix（RBAC）の単体テスト。
+//
+// テスト観点:
+//   - role → resource → action の完全一致 / ワイルドカード
+//   - matrix 未設定時は admin role のみ全許可（従来挙動）
+//   - RequirePermission の通過・拒否、JSON 読込（null matrix は拒否）
+
+package auth
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPermissionMatrix_Allows(t *testing.T) {
+	m, err := ParsePermissionMatrix([]byte(`{"operator":{"stock":["read","reconcile"]},"auditor":{"*":["read"]},"owner":{"stock":["*"]}}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cases := []struct {
+		roles            []string
+		resource, action string
+		want             bool
+	}{
+		{[]string{"operator"}, "stock", "reconcile", true},
+		{[]string{"operator"}, "stock", "delete", false},
+		{[]string{"operator"}, "notification", "read", false},
+		{[]string{"auditor"}, "notification", "read", true},
+		{[]string{"auditor"}, "stock", "reconcile", false},
+		{[]string{"owner"}, "stock", "delete", true},
+		{[]string{"user", "operator"}, "stock", "read", true},
+		// matrix 設定時は admin も明示的な許可が必要。
+		{[]string{"admin"}, "stock", "read", false},
+	}
+	for _, c := range cases {
+		if got := m.Allows(c.roles, c.resource, c.action); got != c.want {
+			t.Errorf("Allows(%v, %s, %s) = %v, want %v", c.roles, c.resource, c.action, got, c.want)
+		}
+	}
+}
+
+func TestPermissionMatrix_NilFallsBackToAdmin(t *testing.T) {
+	var m PermissionMatrix
+	if !m.Allows([]string{"admin"}, "stock", "delete") {
+		t.Errorf("admin should be allowed without matrix")
+	}
+	if m.Allows([]string{"operator"}, "stock", "read") {
+		t.Errorf("non-admin should be denied without matrix")
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	m := PermissionMatrix{"operator": {"stock": {"reconcile"}}}
+	mw := RequirePermission(m, "stock", "reconcile")
+	if got := serve(mw, nil, guardRequest([]string{"operator"}, nil)).Code; got != http.StatusOK {
+		t.Errorf("permitted role should pass; got %d", got)
+	}
+	if got := serve(mw, nil, guardRequest([]string{"user"}, nil)).Code; got != http.StatusForbidden {
+		t.Errorf("unpermitted role should be 403; got %d", got)
+	}
+}
+
+func TestLoadPermissionMatrix(t *testing.T) {
+	if m, err := LoadPermissionMatrix(""); m != nil || err != nil {
+		t.Fatalf("empty path should be nil fallback: m=%v err=%v", m, err)
+	}
+	path := filepath.Join(t.TempDir(), "perms.json")
+	if err := os.WriteFile(path, []byte(`{"operator":{"stock":["read"]}}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m, err := LoadPermissionMatrix(path)
+	if err != nil || !m.Allows([]string{"operator"}, "stock", "read") {
+		t.Fatalf("load: m=%v err=%v", m, err)
+	}
+	if _, err := LoadPermissionMatrix(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatalf("missing file should error")
+	}
+}
+
+func TestParsePermissionMatrix_RejectsNull(t *testing.T) {
+	// null は admin fallback に化けるため拒否し、{} は全拒否の matrix として受け付ける。
+	for _, raw := range []string{`null`, ` null `} {
+		if m, err := ParsePermissionMatrix([]byte(raw)); err == nil {
+			t.Errorf("%q should be rejected, got %v", raw, m)
+		}
+	}
+	m, err := ParsePermissionMatrix([]byte(`{}`))
+	if err != nil || m == nil {
+		t.Fatalf("{} should parse to an empty matrix: m=%v err=%v", m, err)
+	}
+	if m.Allows([]string{"admin"}, "stock", "read") {
+		t.Errorf("empty matrix should deny admin")
+	}
+}
@@ -0,0 +1,144 @@
+// 本ファイルは tier2 共通 auth middleware の role / scope 認可 guard。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-002（RBAC）
+//
+// 役割:
+//   Required() の後段に重ねる合成可能な middleware を提供し、各 handler が
+//   HasRole を組み合わせた真偽ロジックを再実装しないようにする:
+//     - RequireAnyRole  : 指定 role のいずれかを持てば通過
+//     - RequireAllRoles : 指定 role を全て持てば通過
+//     - RequireScope    : scope クレームに指定 scope を全て含めば通過
+//   いずれも認証済 context を前提とし、不足時は 403（E-T2-AUTH-002）を返す。
+//   Config.AuditHook が設定されていれば拒否を hook にも通知する。
+//   off mode は roles / scopes を持たないため、これらの guard は常に 403 になる（fail closed）。
+//
+// 利用例:
+//   mux.Handle("/admin/", t2auth.Required()(t2auth.RequireAnyRole("admin", "operator")(adminMux)))
+
+package auth
+
+// 標準 import。
+import (
+	// context 伝搬。
+	"context"
+	// エラー応答の JSON エンコード。
+	"encoding/json"
+	// HTTP server。
+	"net/http"
+	// 不足項目のメッセージ整形。
+	"strings"
+)
+
+// ScopesFromContext は middleware が attach した scope 配列を返す。
+func ScopesFromContext(ctx context.Context) []string {
+	// nil context 防御。
+	if ctx == nil {
+		// nil を返す。
+		return nil
+	}
+	// 型アサーション。
+	v, ok := ctx.Value(ScopesKey).([]string)
+	// 不在は nil。
+	if !ok {
+		// nil を返す。
+		return nil
+	}
+	// 値を返す。
+	return v
+}
+
+// HasScope は context 内 scopes が指定 scope を含むかを判定する。
+func HasScope(ctx context.Context, scope string) bool {
+	// 完全一致で探索する。
+	return containsString(ScopesFromContext(ctx), scope)
+}
+
+// RequireAnyRole は roles のいずれかを持つ要求のみ通す middleware を返す。
+func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+	return guard(func(ctx context.Context) (bool, string) {
+		// 1 つでも一致すれば通過。
+		have := RolesFromContext(ctx)
+		for _, role := range roles {
+			if containsString(have, role) {
+				return true, ""
+			}
+		}
+		// 不足内容を返す。
+		return false, "missing any of roles: " + strings.Join(roles, ", ")
+	})
+}
+
+// RequireAllRoles は roles を全て持つ要求のみ通す middleware を返す。
+func RequireAllRoles(roles ...string) func(http.Handler) http.Handler {
+	return guard(func(ctx context.Context) (bool, string) {
+		// 欠けている role を全て挙げる。
+		return requireAll(RolesFromContext(ctx), roles, "missing roles: ")
+	})
+}
+
+// RequireScope は scopes を全て含む要求のみ通す middleware を返す。
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return guard(func(ctx context.Context) (bool, string) {
+		// 欠けている scope を全て挙げる。
+		return requireAll(ScopesFromContext(ctx), scopes, "missing scopes: ")
+	})
+}
+
+// guard は check が false を返した要求を 403 で拒否する middleware を組み立てる。
+func guard(check func(ctx context.Context) (bool, string)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 判定して不足なら拒否する。
+			if ok, reason := check(r.Context()); !ok {
+				auditDenied(r.Context(), auditHookFromContext(r.Context()), r, http.StatusForbidden, reason)
+				writeForbidden(w, reason)
+				return
+			}
+			// 通過。
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireAll は want が全て have に含まれるかを判定し、不足時は prefix 付きの理由を返す。
+func requireAll(have, want []string, prefix string) (bool, string) {
+	// 不足分を集める。
+	var missing []string
+	for _, w := range want {
+		if !containsString(have, w) {
+			missing = append(missing, w)
+		}
+	}
+	// 全て揃っていれば通過。
+	if len(missing) == 0 {
+		return true, ""
+	}
+	// 不足内容を返す。
+	return false, prefix + strings.Join(missing, ", ")
+}
+
+// containsString は list に s が含まれるかを返す（完全一致）。
+func containsString(list []string, s string) bool {
+	// 線形探索。
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	// 不在。
+	return false
+}
+
+// writeForbidden は 403 + JSON error を返す。
+func writeForbidden(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":     "E-T2-AUTH-002",
+			"message":  msg,
+			"category": "FORBIDDEN",
+		},
+	})
+}
@@ -0,0 +1,79 @@
+// 本ファイルは role / scope 認可 guard の単体テスト。
+//
+// テスト観点:
+//   - RequireAnyRole / RequireAllRoles / RequireScope の通過・拒否条件
+//   - scope クレームが空白区切りで分解され ScopesKey に attach される
+//   - off mode では roles / scopes が無く guard は 403（fail closed）
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// guardRequest は Required 通過後と同じく roles / scopes を context に積んだ GET /x を返す。
+func guardRequest(roles, scopes []string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	ctx := context.WithValue(req.Context(), RolesKey, roles)
+	ctx = context.WithValue(ctx, ScopesKey, scopes)
+	return req.WithContext(ctx)
+}
+
+func TestRequireAnyRole(t *testing.T) {
+	mw := RequireAnyRole("admin", "operator")
+	if got := serve(mw, nil, guardRequest([]string{"user", "operator"}, nil)).Code; got != http.StatusOK {
+		t.Errorf("one matching role should pass; got %d", got)
+	}
+	if got := serve(mw, nil, guardRequest([]string{"user"}, nil)).Code; got != http.StatusForbidden {
+		t.Errorf("no matching role should be 403; got %d", got)
+	}
+}
+
+func TestRequireAllRoles(t *testing.T) {
+	mw := RequireAllRoles("admin", "auditor")
+	if got := serve(mw, nil, guardRequest([]string{"auditor", "admin"}, nil)).Code; got != http.StatusOK {
+		t.Errorf("all roles should pass; got %d", got)
+	}
+	if got := serve(mw, nil, guardRequest([]string{"admin"}, nil)).Code; got != http.StatusForbidden {
+		t.Errorf("partial roles should be 403; got %d", got)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	mw := RequireScope("stock:read", "stock:write")
+	if got := serve(mw, nil, guardRequest(nil, []string{"openid", "stock:read", "stock:write"})).Code; got != http.StatusOK {
+		t.Errorf("all scopes should pass; got %d", got)
+	}
+	if got := serve(mw, nil, guardRequest(nil, []string{"stock:read"})).Code; got != http.StatusForbidden {
+		t.Errorf("missing scope should be 403; got %d", got)
+	}
+}
+
+func TestScopeClaimIsParsed(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	// 連続空白も区切りとして扱うことを確かめるため scope は生の文字列で与える。
+	token := authtest.HMACToken(t, secret, authtest.Claims{Extra: map[string]any{"scope": "openid  stock:read"}})
+	var got []string
+	serve(RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret}), func(w http.ResponseWriter, r *http.Request) {
+		got = ScopesFromContext(r.Context())
+	}, bearerRequest(token))
+	if len(got) != 2 || got[0] != "openid" || got[1] != "stock:read" {
+		t.Fatalf("scopes = %v", got)
+	}
+}
+
+func TestGuardsFailClosedInOffMode(t *testing.T) {
+	h := RequiredWithConfig(Config{Mode: AuthModeOff})(RequireAnyRole("admin")(http.HandlerFunc(passthroughHandler)))
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("off mode has no roles and should be 403; got %d", rec.Code)
+	}
+}
@@ -0,0 +1,142 @@
+// 本ファイルは tier2 共通 auth middleware の検証結果キャッシュ。
+//
+// docs 正典:
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md NFR-E-AC-001
+//
+// 役割:
+//   同じ token を短時間に繰り返し受ける hot path（BFF からの連続呼出等）で、署名検証
+//   （RSA / ECDSA）を毎回やり直さないよう、検証に成功した token の識別情報を保持する（opt-in。
+//   Config.VerifyCache が nil なら毎回検証する）。
+//     - キーは token の SHA-256（生 token をメモリに残さない）
+//     - 有効期間は VerifyCacheConfig.TTL と token の exp の早い方（exp 後に hit しない）
+//     - 件数は MaxEntries で上限を設け、超過時は最も長く参照されていない entry を捨てる（LRU）
+//     - 成功のみ保持し、失敗した token は毎回検証する（失敗理由の計測を歪めない）
+//   hit / miss は Metrics.ObserveVerifyCache に通知する。DPoP proof / ClaimsMapper は
+//   要求ごとに異なり得るため cache 対象外で、hit 時も毎回実行する。
+//   HMAC 秘密鍵 / JWKS の失効は TTL 経過まで反映されないため、TTL は短く（数十秒）保つ。
+
+package auth
+
+// 標準 import。
+import (
+	// LRU の参照順。
+	"container/list"
+	// token のハッシュ。
+	"crypto/sha256"
+	// 排他制御。
+	"sync"
+	// 有効期間。
+	"time"
+)
+
+// VerifyCacheConfig は検証結果キャッシュの設定。
+type VerifyCacheConfig struct {
+	// entry の最大保持時間（token の exp が早ければそちらを優先）。0 で 30 秒既定。
+	TTL time.Duration
+	// 保持する token の最大件数。0 で 10000 件既定。
+	MaxEntries int
+}
+
+// verifyEntry は検証済 token 1 件。
+type verifyEntry struct {
+	// token の SHA-256。
+	key [sha256.Size]byte
+	// 検証済の識別情報。
+	id *identity
+	// entry の失効時刻。
+	expiresAt time.Time
+}
+
+// verifyCache は token ハッシュ → 検証済識別情報の LRU（複数 goroutine 安全）。
+type verifyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[[sha256.Size]byte]*list.Element
+	// 先頭ほど最近参照した entry。
+	order   *list.List
+	metrics Metrics
+}
+
+// newVerifyCache は cfg から cache を作る。未設定 / off mode では nil（cache しない）。
+func newVerifyCache(cfg Config) *verifyCache {
+	// opt-in でなければ不要、off mode は検証自体を行わない。
+	if cfg.VerifyCache == nil || cfg.Mode == AuthModeOff {
+		// nil を返す。
+		return nil
+	}
+	// 既定値を補う。
+	c := &verifyCache{ttl: cfg.VerifyCache.TTL, max: cfg.VerifyCache.MaxEntries, order: list.New(), metrics: metricsOf(cfg)}
+	if c.ttl <= 0 {
+		c.ttl = 30 * time.Second
+	}
+	if c.max <= 0 {
+		c.max = 10000
+	}
+	c.entries = make(map[[sha256.Size]byte]*list.Element)
+	// 組み立てた cache を返す。
+	return c
+}
+
+// verify は token の検証済識別情報を返す。cache に無ければ verify を呼び、成功時に保持する。
+func (c *verifyCache) verify(token string, verify func() (*identity, error)) (*identity, error) {
+	// cache 無効時は毎回検証する。
+	if c == nil {
+		return verify()
+	}
+	key := sha256.Sum256([]byte(token))
+	if id, ok := c.get(key); ok {
+		c.metrics.ObserveVerifyCache(true)
+		return id, nil
+	}
+	c.metrics.ObserveVerifyCache(false)
+	// 検証は lock 外で行い、同時 miss は各自検証する（結果は同じ）。
+	id, err := verify()
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, id)
+	return id, nil
+}
+
+// get は有効な entry を返し、参照順を更新する。失効済みは捨てる。
+func (c *verifyCache) get(key [sha256.Size]byte) (*identity, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*verifyEntry)
+	if !time.Now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return e.id, true
+}
+
+// put は id を保持する。有効期間は TTL と exp の早い方で、上限超過時は LRU 末尾を捨てる。
+func (c *verifyCache) put(key [sha256.Size]byte, id *identity) {
+	expiresAt := time.Now().Add(c.ttl)
+	if !id.expiry.IsZero() && id.expiry.Before(expiresAt) {
+		expiresAt = id.expiry
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = &verifyEntry{key: key, id: id, expiresAt: expiresAt}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&verifyEntry{key: key, id: id, expiresAt: expiresAt})
+	for c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove は el を map / 参照順の両方から外す（呼出側が lock を保持する）。
+func (c *verifyCache) remove(el *list.Element) {
+	delete(c.entries, el.Value.(*verifyEntry).key)
+	c.order.Remove(el)
+}
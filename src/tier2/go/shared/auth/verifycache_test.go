@@ -0,0 +1,116 @@
+// 本ファイルは検証結果キャッシュの単体テスト。
+//
+// テスト観点:
+//   - 同一 token の 2 回目以降は署名検証を省き、hit / miss を Metrics に通知する
+//   - 有効期間は TTL と exp の早い方、失敗した token は保持しない
+//   - MaxEntries を超えると最も長く参照されていない entry を捨てる
+//   - 未設定時は毎回検証する
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/k1s0/k1s0/src/tier2/go/shared/auth/authtest"
+)
+
+// countingVerify は呼出回数を数え、id を返す検証関数を作る。
+func countingVerify(calls *int, id *identity, err error) func() (*identity, error) {
+	return func() (*identity, error) {
+		*calls++
+		return id, err
+	}
+}
+
+func TestVerifyCache_MiddlewareSkipsReverification(t *testing.T) {
+	secret := []byte("test-secret-32-bytes-long-enough!")
+	m := &recordingMetrics{}
+	mw := RequiredWithConfig(Config{Mode: AuthModeHMAC, HMACSecret: secret, Metrics: m, VerifyCache: &VerifyCacheConfig{}})
+	tok := authtest.HMACToken(t, secret, authtest.Claims{Subject: "u1"})
+	for range 3 {
+		if rec := serve(mw, nil, bearerRequest(tok)); rec.Code != http.StatusOK || rec.Header().Get("X-Subject") != "u1" {
+			t.Fatalf("status=%d sub=%q", rec.Code, rec.Header().Get("X-Subject"))
+		}
+	}
+	if m.verifyMisses != 1 || m.verifyHits != 2 {
+		t.Fatalf("misses=%d hits=%d, want 1/2", m.verifyMisses, m.verifyHits)
+	}
+	// 署名不正の token は cache されず毎回 401。
+	bad := authtest.HMACToken(t, []byte("wrong-secret-32-bytes-long-enough"), authtest.Claims{})
+	for range 2 {
+		if rec := serve(mw, nil, bearerRequest(bad)); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("bad token status = %d", rec.Code)
+		}
+	}
+	if m.verifyHits != 2 {
+		t.Fatalf("failed token must not hit, hits=%d", m.verifyHits)
+	}
+}
+
+func TestVerifyCache_BoundedByExpiry(t *testing.T) {
+	c := newVerifyCache(Config{Mode: AuthModeHMAC, VerifyCache: &VerifyCacheConfig{TTL: time.Hour}})
+	calls := 0
+	// exp が TTL より早い token は exp で失効する。
+	v := countingVerify(&calls, &identity{subject: "u1", expiry: time.Now().Add(20 * time.Millisecond)}, nil)
+	_, _ = c.verify("t1", v)
+	_, _ = c.verify("t1", v)
+	if calls != 1 {
+		t.Fatalf("second call should hit, calls=%d", calls)
+	}
+	time.Sleep(30 * time.Millisecond)
+	_, _ = c.verify("t1", v)
+	if calls != 2 {
+		t.Fatalf("entry past exp should be re-verified, calls=%d", calls)
+	}
+}
+
+func TestVerifyCache_DoesNotCacheFailures(t *testing.T) {
+	c := newVerifyCache(Config{Mode: AuthModeHMAC, VerifyCache: &VerifyCacheConfig{}})
+	calls := 0
+	v := countingVerify(&calls, nil, errors.New("verify: bad signature"))
+	for range 2 {
+		if _, err := c.verify("t1", v); err == nil {
+			t.Fatalf("failure should be returned")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("failures must be re-verified, calls=%d", calls)
+	}
+}
+
+func TestVerifyCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newVerifyCache(Config{Mode: AuthModeHMAC, VerifyCache: &VerifyCacheConfig{MaxEntries: 2}})
+	calls := 0
+	v := countingVerify(&calls, &identity{subject: "u"}, nil)
+	_, _ = c.verify("a", v)
+	_, _ = c.verify("b", v)
+	// a を参照して b を最古にする。
+	_, _ = c.verify("a", v)
+	_, _ = c.verify("c", v)
+	if c.order.Len() != 2 {
+		t.Fatalf("size = %d, want 2", c.order.Len())
+	}
+	calls = 0
+	_, _ = c.verify("a", v)
+	_, _ = c.verify("b", v)
+	if calls != 1 {
+		t.Fatalf("only b should have been evicted, calls=%d", calls)
+	}
+}
+
+func TestVerifyCache_DisabledVerifiesEveryTime(t *testing.T) {
+	if newVerifyCache(Config{Mode: AuthModeHMAC}) != nil || newVerifyCache(Config{Mode: AuthModeOff, VerifyCache: &VerifyCacheConfig{}}) != nil {
+		t.Fatalf("cache should be nil when not configured or in off mode")
+	}
+	var c *verifyCache
+	calls := 0
+	v := countingVerify(&calls, &identity{subject: "u"}, nil)
+	_, _ = c.verify("t1", v)
+	_, _ = c.verify("t1", v)
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
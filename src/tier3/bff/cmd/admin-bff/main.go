@@ -13,6 +13,8 @@ import (
 	"errors"
 	// log。
 	"log"
+	// アクセスログ用の構造化ログ。
+	"log/slog"
 	// HTTP。
 	"net/http"
 	// signal handling。
@@ -28,6 +30,10 @@ import (
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
 	// k1s0 SDK ラッパー。
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/k1s0client"
+	// readiness probe。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/health"
+	// 共通 HTTP middleware（CORS / アクセスログ / セキュリティヘッダ等）。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/middleware"
 	// REST router。
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/rest"
 	// shared OTel ヘルパ。
@@ -71,22 +77,36 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte("ready"))
-	})
+	// readiness は tier1 と（READINESS_PROBE_JWKS=true かつ jwks mode 時は）IdP の JWKS への到達性を確認し、失敗時は 503 を返す。
+	probes := []health.DependencyProbe{{Name: "tier1", Check: client.CheckReady}}
+	if authCfg := auth.LoadConfigFromEnv(); cfg.Readiness.ProbeJWKS && authCfg.Mode == auth.AuthModeJWKS {
+		probes = append(probes, health.DependencyProbe{Name: "jwks", Check: func(ctx context.Context) error {
+			return auth.CheckJWKS(ctx, authCfg)
+		}})
+	}
+	mux.Handle("GET /readyz", health.Readiness(time.Duration(cfg.Readiness.ProbeTimeoutMs)*time.Millisecond, probes...))
 	// REST（認可: role=admin）。
 	router := rest.NewRouter(client)
 	restMux := http.NewServeMux()
 	router.Register(restMux)
 	mux.Handle("/api/", auth.Required("admin")(restMux))
+	// CORS は auth より外側（mux 全体）に被せ、preflight が認可で弾かれないようにする。
+	handler := middleware.CORS(cfg.CORS)(mux)
+	// body サイズ / 読込 deadline の制限は CORS より外側で全要求に適用する。
+	handler = middleware.BodyLimit(cfg.HTTP)(handler)
+	// セキュリティヘッダは 413 等の早期応答にも付くよう BodyLimit より外側に被せる。
+	handler = middleware.SecurityHeaders(cfg.SecurityHeaders)(handler)
+	// アクセスログは最外周に被せ、preflight / 401 / 403 も記録する。
+	accessLogger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With(slog.String("service", cfg.AppName))
+	handler = middleware.AccessLog(cfg.AccessLog, accessLogger)(handler)
 	// HTTP server。
 	srv := &http.Server{
-		Addr:         cfg.HTTP.Addr,
-		Handler:      mux,
-		ReadTimeout:  time.Duration(cfg.HTTP.ReadTimeoutSec) * time.Second,
-		WriteTimeout: time.Duration(cfg.HTTP.WriteTimeoutSec) * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              cfg.HTTP.Addr,
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.HTTP.ReadTimeoutSec) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.HTTP.ReadHeaderTimeoutSec) * time.Second,
+		WriteTimeout:      time.Duration(cfg.HTTP.WriteTimeoutSec) * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 	errCh := make(chan error, 1)
 	go func() {
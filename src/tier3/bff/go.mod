@@ -18,6 +18,8 @@ go 1.25.0
 require (
 	github.com/go-jose/go-jose/v4 v4.1.4
 	github.com/k1s0/sdk-go v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.79.3
+	google.golang.org/protobuf v1.36.11
 )
 
 require (
@@ -26,8 +28,6 @@ require (
 	golang.org/x/text v0.32.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20260427160629-7cedc36a6bc4 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20260420184626-e10c466a9529 // indirect
-	google.golang.org/grpc v1.79.3 // indirect
-	google.golang.org/protobuf v1.36.11 // indirect
 )
 
 // docs 正典: docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/01_tier3全体配置.md
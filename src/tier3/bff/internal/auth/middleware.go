@@ -43,6 +43,7 @@ import (
 	"github.com/go-jose/go-jose/v4/jwt"
 
 	bffErrors "github.com/k1s0/k1s0/src/tier3/bff/internal/shared/errors"
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/shared/reqinfo"
 )
 
 // contextKey は context 経由でユーザ識別を渡す際のキー。
@@ -167,6 +168,38 @@ func (c *jwksCache) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
 	return c.jwks, nil
 }
 
+// CheckJWKS は cfg.JWKSURL の JWKS endpoint に到達でき、鍵集合として解釈できるかを確認する（/readyz 用）。
+// cache は使わず毎回取得するため、main は READINESS_PROBE_JWKS=true の時だけ probe に加える。timeout は呼出側の ctx で与える。
+func CheckJWKS(ctx context.Context, cfg Config) error {
+	if cfg.JWKSURL == "" {
+		return errors.New("jwks url not configured")
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("jwks probe: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jwks probe: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks probe: HTTP %d", resp.StatusCode)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return fmt.Errorf("jwks probe: decode: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return errors.New("jwks probe: empty key set")
+	}
+	return nil
+}
+
 // requiredWithConfig は cfg を使う Required 内部実装。test で cfg を差し替えるために分離する。
 func requiredWithConfig(cfg Config, requireRole string) func(http.Handler) http.Handler {
 	var jwks *jwksCache
@@ -212,6 +245,8 @@ func requiredWithConfig(cfg Config, requireRole string) func(http.Handler) http.
 					return
 				}
 			}
+			// 外側の AccessLog に subject を通知する（未経由なら no-op）。
+			reqinfo.SetSubject(r.Context(), subject)
 			ctx := context.WithValue(r.Context(), SubjectKey, subject)
 			ctx = context.WithValue(ctx, RolesKey, roles)
 			ctx = context.WithValue(ctx, TenantIDKey, tenantID)
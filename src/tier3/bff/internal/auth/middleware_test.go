@@ -299,3 +299,33 @@ func TestContextHelpers_NoValue_ReturnsEmpty(t *testing.T) {
 		t.Errorf("roles should be nil")
 	}
 }
+
+// readiness: JWKS endpoint が鍵集合を返すときだけ到達可能とみなす。
+func TestCheckJWKS(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa keygen: %v", err)
+	}
+	status := http.StatusOK
+	keys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &priv.PublicKey, KeyID: "k1", Use: "sig"}}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(keys)
+	}))
+	defer srv.Close()
+	cfg := Config{Mode: AuthModeJWKS, JWKSURL: srv.URL, HTTPClient: srv.Client()}
+	if err := CheckJWKS(context.Background(), cfg); err != nil {
+		t.Fatalf("reachable jwks: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := CheckJWKS(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("503 should fail, got %v", err)
+	}
+	status, keys = http.StatusOK, jose.JSONWebKeySet{}
+	if err := CheckJWKS(context.Background(), cfg); err == nil {
+		t.Errorf("empty key set should fail")
+	}
+	if err := CheckJWKS(context.Background(), Config{Mode: AuthModeJWKS}); err == nil {
+		t.Errorf("missing url should fail")
+	}
+}
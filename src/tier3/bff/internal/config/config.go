@@ -29,6 +29,14 @@ type Config struct {
 	HTTP HTTPConfig
 	// k1s0 facade 接続設定。
 	K1s0 K1s0Config
+	// CORS 設定（SPA を別 origin に配置する場合のみ有効化する）。
+	CORS CORSConfig
+	// アクセスログ設定。
+	AccessLog AccessLogConfig
+	// セキュリティヘッダ設定（既定値は Environment で切り替わる）。
+	SecurityHeaders SecurityHeadersConfig
+	// readiness probe 設定。
+	Readiness ReadinessConfig
 }
 
 // HTTPConfig は HTTP server の設定。
@@ -36,6 +44,12 @@ type HTTPConfig struct {
 	Addr            string
 	ReadTimeoutSec  int
 	WriteTimeoutSec int
+	// header 読込の上限秒数（slowloris 対策）。
+	ReadHeaderTimeoutSec int
+	// 要求単位の body 読込 deadline 秒数（0 で無効）。
+	BodyReadTimeoutSec int
+	// 受け付ける body の最大バイト数（0 で無制限）。
+	MaxBodyBytes int64
 }
 
 // K1s0Config は k1s0 SDK Client 構築時の設定。
@@ -44,6 +58,63 @@ type K1s0Config struct {
 	TenantID string
 	Subject  string
 	UseTLS   bool
+	// mTLS のクライアント証明書 / 秘密鍵の PEM ファイル（Vault Agent / cert-manager が配置・更新する）。
+	TLSCertFile string
+	TLSKeyFile  string
+	// tier1 のサーバ証明書を検証する私設 CA bundle の PEM ファイル（空ならシステムの CA、更新は次の handshake で反映）。
+	TLSCAFile string
+	// サーバ証明書の検証に使う名前（空なら Target の host 部）。
+	TLSServerName string
+}
+
+// CORSConfig は CORS middleware の設定。
+// AllowedOrigins が空なら CORS は無効（Access-Control-* ヘッダを一切付与しない）。
+type CORSConfig struct {
+	// 許可 origin。"*" は全許可、"https://*.example.com" はサブドメインのワイルドカード。
+	AllowedOrigins []string
+	// preflight で返す許可メソッド。
+	AllowedMethods []string
+	// preflight で返す許可リクエストヘッダ。
+	AllowedHeaders []string
+	// Cookie / Authorization 付き cross-origin 要求を許可するか。
+	AllowCredentials bool
+	// preflight 結果のブラウザキャッシュ秒数。
+	MaxAgeSec int
+}
+
+// AccessLogConfig はアクセスログ middleware の設定。
+type AccessLogConfig struct {
+	// アクセスログを出力するか。
+	Enabled bool
+	// 成功応答の出力率（0.0〜1.0）。5xx は常に出力する。
+	SampleRate float64
+	// 出力対象外のパス（完全一致）。
+	ExcludePaths []string
+}
+
+// SecurityHeadersConfig はセキュリティヘッダ middleware の設定。
+// 空文字 / 0 の項目は対応するヘッダを付与しない（X-Content-Type-Options は常に付与）。
+type SecurityHeadersConfig struct {
+	// セキュリティヘッダを付与するか。
+	Enabled bool
+	// Content-Security-Policy の値。
+	ContentSecurityPolicy string
+	// Strict-Transport-Security の max-age 秒数。
+	HSTSMaxAgeSec int
+	// HSTS に includeSubDomains を付けるか。
+	HSTSIncludeSubdomains bool
+	// Referrer-Policy の値。
+	ReferrerPolicy string
+	// X-Frame-Options の値（DENY / SAMEORIGIN）。
+	FrameOptions string
+}
+
+// ReadinessConfig は /readyz の依存先確認の設定。
+type ReadinessConfig struct {
+	// 依存先 1 件あたりの確認 timeout ミリ秒（kubelet の probe timeout より短くする）。
+	ProbeTimeoutMs int
+	// jwks mode 時に IdP の JWKS 到達性も確認するか（既定 false、IdP の短時間障害で NotReady にしない）。
+	ProbeJWKS bool
 }
 
 // Load は appName を引数に受け、環境変数から Config を組み立てる。
@@ -63,6 +134,10 @@ func Load(appName string) (*Config, error) {
 			Addr:            getenvDefault("HTTP_ADDR", ":8080"),
 			ReadTimeoutSec:  getenvIntDefault("HTTP_READ_TIMEOUT_SEC", 15),
 			WriteTimeoutSec: getenvIntDefault("HTTP_WRITE_TIMEOUT_SEC", 15),
+			// slow-client 対策（header 5 秒 / body 10 秒 / 1 MiB）。
+			ReadHeaderTimeoutSec: getenvIntDefault("HTTP_READ_HEADER_TIMEOUT_SEC", 5),
+			BodyReadTimeoutSec:   getenvIntDefault("HTTP_BODY_READ_TIMEOUT_SEC", 10),
+			MaxBodyBytes:         int64(getenvIntDefault("HTTP_MAX_BODY_BYTES", 1<<20)),
 		},
 		// k1s0 SDK 設定（subject はアプリ名で正規化）。
 		K1s0: K1s0Config{
@@ -70,8 +145,34 @@ func Load(appName string) (*Config, error) {
 			TenantID: os.Getenv("K1S0_TENANT_ID"),
 			Subject:  getenvDefault("K1S0_SUBJECT", "tier3/"+appName),
 			UseTLS:   getenvBoolDefault("K1S0_USE_TLS", false),
+			// mTLS / 私設 CA（既定は無し = SDK と同じ TLS 1.3 + システム CA）。
+			TLSCertFile:   os.Getenv("K1S0_TLS_CERT_FILE"),
+			TLSKeyFile:    os.Getenv("K1S0_TLS_KEY_FILE"),
+			TLSCAFile:     os.Getenv("K1S0_TLS_CA_FILE"),
+			TLSServerName: os.Getenv("K1S0_TLS_SERVER_NAME"),
+		},
+		// CORS（既定は無効、メソッド / ヘッダは REST / GraphQL で使う最小集合）。
+		CORS: CORSConfig{
+			AllowedOrigins:   getenvListDefault("CORS_ALLOWED_ORIGINS", nil),
+			AllowedMethods:   getenvListDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getenvListDefault("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type"}),
+			AllowCredentials: getenvBoolDefault("CORS_ALLOW_CREDENTIALS", false),
+			MaxAgeSec:        getenvIntDefault("CORS_MAX_AGE_SEC", 600),
+		},
+		// アクセスログ（既定は全件出力、probe 系パスは除外）。
+		AccessLog: AccessLogConfig{
+			Enabled:      getenvBoolDefault("ACCESS_LOG_ENABLED", true),
+			SampleRate:   getenvFloatDefault("ACCESS_LOG_SAMPLE_RATE", 1.0),
+			ExcludePaths: getenvListDefault("ACCESS_LOG_EXCLUDE_PATHS", []string{"/healthz", "/readyz", "/metrics"}),
+		},
+		// readiness（kubelet 既定 timeout 1 秒以内に収まる 800ms）。
+		Readiness: ReadinessConfig{
+			ProbeTimeoutMs: getenvIntDefault("READINESS_PROBE_TIMEOUT_MS", 800),
+			ProbeJWKS:      getenvBoolDefault("READINESS_PROBE_JWKS", false),
 		},
 	}
+	// セキュリティヘッダは環境別の既定値を env で上書きする。
+	cfg.SecurityHeaders = loadSecurityHeaders(cfg.Environment)
 	// 必須項目の検証。
 	if err := cfg.validate(); err != nil {
 		return nil, err
@@ -91,6 +192,30 @@ func (c *Config) validate() error {
 	if c.AppName == "" {
 		return fmt.Errorf("config: appName is required")
 	}
+	// クライアント証明書と秘密鍵は対で指定する。
+	if (c.K1s0.TLSCertFile == "") != (c.K1s0.TLSKeyFile == "") {
+		return fmt.Errorf("config: K1S0_TLS_CERT_FILE and K1S0_TLS_KEY_FILE must be set together")
+	}
+	// TLS 設定は平文接続では使われないため、設定漏れとして起動時に弾く。
+	if !c.K1s0.UseTLS && (c.K1s0.TLSCertFile != "" || c.K1s0.TLSCAFile != "") {
+		return fmt.Errorf("config: K1S0_TLS_* requires K1S0_USE_TLS=true")
+	}
+	// サンプリング率は確率値のみ受け付ける。
+	if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 {
+		return fmt.Errorf("config: ACCESS_LOG_SAMPLE_RATE must be within [0, 1]")
+	}
+	// timeout 無しの probe は依存先の hang で readiness 自体が応答しなくなる。
+	if c.Readiness.ProbeTimeoutMs <= 0 {
+		return fmt.Errorf("config: READINESS_PROBE_TIMEOUT_MS must be positive")
+	}
+	// credentials 付きで全 origin を許可する組合せはブラウザが拒否し、かつ危険なので起動時に弾く。
+	if c.CORS.AllowCredentials {
+		for _, o := range c.CORS.AllowedOrigins {
+			if o == "*" {
+				return fmt.Errorf("config: CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS")
+			}
+		}
+	}
 	return nil
 }
 
@@ -128,3 +253,65 @@ func getenvBoolDefault(key string, def bool) bool {
 		return def
 	}
 }
+
+// getenvListDefault はカンマ区切りの env を trim 済みスライスに分解する。
+// 未設定 / 空要素のみの場合は def を返す。
+func getenvListDefault(key string, def []string) []string {
+	v := os.Getenv(key)
+	if strings.TrimSpace(v) == "" {
+		return def
+	}
+	out := make([]string, 0)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	if len(out) == 0 {
+		return def
+	}
+	return out
+}
+
+// getenvFloatDefault は env を float64 として読む。未設定 / 不正値は def を返す。
+func getenvFloatDefault(key string, def float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+// loadSecurityHeaders は environment 別の既定値に env の上書きを適用する。
+// dev はローカル SPA / Swagger UI 等から叩けるよう緩和し、それ以外は JSON API 前提の厳格値にする。
+func loadSecurityHeaders(environment string) SecurityHeadersConfig {
+	// 厳格な既定値（staging / prod）。BFF は HTML を返さないため CSP は全拒否でよい。
+	def := SecurityHeadersConfig{
+		Enabled:               true,
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		HSTSMaxAgeSec:         31536000,
+		HSTSIncludeSubdomains: true,
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "DENY",
+	}
+	// dev は平文 HTTP 運用があり得るため HSTS を外し、CSP も付与しない。
+	if environment == "dev" {
+		def = SecurityHeadersConfig{
+			Enabled:        true,
+			ReferrerPolicy: "strict-origin-when-cross-origin",
+			FrameOptions:   "SAMEORIGIN",
+		}
+	}
+	return SecurityHeadersConfig{
+		Enabled:               getenvBoolDefault("SECURITY_HEADERS_ENABLED", def.Enabled),
+		ContentSecurityPolicy: getenvDefault("SECURITY_HEADERS_CSP", def.ContentSecurityPolicy),
+		HSTSMaxAgeSec:         getenvIntDefault("SECURITY_HEADERS_HSTS_MAX_AGE_SEC", def.HSTSMaxAgeSec),
+		HSTSIncludeSubdomains: getenvBoolDefault("SECURITY_HEADERS_HSTS_INCLUDE_SUBDOMAINS", def.HSTSIncludeSubdomains),
+		ReferrerPolicy:        getenvDefault("SECURITY_HEADERS_REFERRER_POLICY", def.ReferrerPolicy),
+		FrameOptions:          getenvDefault("SECURITY_HEADERS_FRAME_OPTIONS", def.FrameOptions),
+	}
+}
@@ -126,6 +126,30 @@ func TestLoad_OverridesFromEnv(t *testing.T) {
 	}
 }
 
+func TestLoad_ReadinessJWKSProbeOptIn(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":       "T1",
+		"K1S0_TARGET":          "tier1:50001",
+		"READINESS_PROBE_JWKS": "",
+	})
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// 既定では JWKS を probe しない。
+	if cfg.Readiness.ProbeJWKS {
+		t.Errorf("Readiness.ProbeJWKS default should be false")
+	}
+	t.Setenv("READINESS_PROBE_JWKS", "true")
+	cfg, err = Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Readiness.ProbeJWKS {
+		t.Errorf("Readiness.ProbeJWKS should be true")
+	}
+}
+
 func TestGetenvBoolDefault_AcceptsCommonValues(t *testing.T) {
 	cases := []struct {
 		v    string
@@ -166,3 +190,133 @@ func TestGetenvIntDefault_FallsBackOnError(t *testing.T) {
 		t.Errorf("valid int parse failed, got %d", got)
 	}
 }
+
+func TestLoad_CORSDefaultsDisabled(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":       "T1",
+		"K1S0_TARGET":          "tier1:50001",
+		"CORS_ALLOWED_ORIGINS": "",
+		"CORS_ALLOWED_METHODS": "",
+		"CORS_MAX_AGE_SEC":     "",
+	})
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.CORS.AllowedOrigins) != 0 {
+		t.Errorf("CORS should be disabled by default, got %v", cfg.CORS.AllowedOrigins)
+	}
+	if len(cfg.CORS.AllowedMethods) == 0 || cfg.CORS.MaxAgeSec != 600 {
+		t.Errorf("CORS defaults mismatch: %+v", cfg.CORS)
+	}
+}
+
+func TestLoad_CORSListParsing(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":         "T1",
+		"K1S0_TARGET":            "tier1:50001",
+		"CORS_ALLOWED_ORIGINS":   " https://app.example.com , ,https://*.example.com",
+		"CORS_ALLOW_CREDENTIALS": "true",
+	})
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"https://app.example.com", "https://*.example.com"}
+	if strings.Join(cfg.CORS.AllowedOrigins, "|") != strings.Join(want, "|") {
+		t.Errorf("AllowedOrigins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	if !cfg.CORS.AllowCredentials {
+		t.Errorf("AllowCredentials should be true")
+	}
+}
+
+func TestLoad_CORSRejectsStarWithCredentials(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":         "T1",
+		"K1S0_TARGET":            "tier1:50001",
+		"CORS_ALLOWED_ORIGINS":   "*",
+		"CORS_ALLOW_CREDENTIALS": "true",
+	})
+	_, err := Load("portal-bff")
+	if err == nil || !strings.Contains(err.Error(), "CORS_ALLOWED_ORIGINS") {
+		t.Fatalf("* with credentials should error, got: %v", err)
+	}
+}
+
+func TestLoad_AccessLogSampleRateRange(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":         "T1",
+		"K1S0_TARGET":            "tier1:50001",
+		"ACCESS_LOG_SAMPLE_RATE": "1.5",
+	})
+	_, err := Load("portal-bff")
+	if err == nil || !strings.Contains(err.Error(), "ACCESS_LOG_SAMPLE_RATE") {
+		t.Fatalf("sample rate > 1 should error, got: %v", err)
+	}
+	t.Setenv("ACCESS_LOG_SAMPLE_RATE", "0.25")
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.AccessLog.SampleRate != 0.25 || !cfg.AccessLog.Enabled {
+		t.Errorf("AccessLog = %+v", cfg.AccessLog)
+	}
+}
+
+func TestLoad_SecurityHeadersPerEnvironment(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID": "T1",
+		"K1S0_TARGET":    "tier1:50001",
+		"ENVIRONMENT":    "dev",
+	})
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SecurityHeaders.HSTSMaxAgeSec != 0 || cfg.SecurityHeaders.ContentSecurityPolicy != "" {
+		t.Errorf("dev should relax HSTS / CSP, got %+v", cfg.SecurityHeaders)
+	}
+	t.Setenv("ENVIRONMENT", "prod")
+	cfg, err = Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SecurityHeaders.HSTSMaxAgeSec != 31536000 || cfg.SecurityHeaders.FrameOptions != "DENY" {
+		t.Errorf("prod should be strict, got %+v", cfg.SecurityHeaders)
+	}
+	t.Setenv("SECURITY_HEADERS_FRAME_OPTIONS", "SAMEORIGIN")
+	cfg, err = Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.SecurityHeaders.FrameOptions != "SAMEORIGIN" {
+		t.Errorf("env override not applied: %q", cfg.SecurityHeaders.FrameOptions)
+	}
+}
+
+func TestLoad_K1s0TLSFiles(t *testing.T) {
+	withEnv(t, map[string]string{
+		"K1S0_TENANT_ID":     "T1",
+		"K1S0_TARGET":        "tier1:50001",
+		"K1S0_USE_TLS":       "true",
+		"K1S0_TLS_CERT_FILE": "/vault/secrets/tls.crt",
+	})
+	_, err := Load("portal-bff")
+	if err == nil || !strings.Contains(err.Error(), "K1S0_TLS_KEY_FILE") {
+		t.Fatalf("cert without key should error, got: %v", err)
+	}
+	t.Setenv("K1S0_TLS_KEY_FILE", "/vault/secrets/tls.key")
+	t.Setenv("K1S0_TLS_CA_FILE", "/vault/secrets/ca.crt")
+	cfg, err := Load("portal-bff")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.K1s0.TLSCertFile != "/vault/secrets/tls.crt" || cfg.K1s0.TLSCAFile != "/vault/secrets/ca.crt" {
+		t.Errorf("K1s0 = %+v", cfg.K1s0)
+	}
+	t.Setenv("K1S0_USE_TLS", "false")
+	if _, err := Load("portal-bff"); err == nil || !strings.Contains(err.Error(), "K1S0_USE_TLS") {
+		t.Fatalf("tls files without K1S0_USE_TLS should error, got: %v", err)
+	}
+}
@@ -24,6 +24,8 @@ import (
 	"context"
 	// JSON エンコード / デコード。
 	"encoding/json"
+	// MaxBytesError 判定。
+	"errors"
 	// HTTP server。
 	"net/http"
 	// 文字列処理。
@@ -109,6 +111,12 @@ func (r *Resolver) Handler() http.HandlerFunc {
 		// JSON body をデコードする。
 		var gReq graphqlRequest
 		if err := json.NewDecoder(req.Body).Decode(&gReq); err != nil {
+			// BodyLimit middleware の上限超過は 413 として区別する。
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
 			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -0,0 +1,100 @@
+// readiness probe ハンドラ。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/04_bff配置.md
+//
+// 役割:
+//   /readyz で BFF の依存先（tier1 facade / IdP の JWKS）への到達性を確認し、
+//   依存先ごとの結果を JSON で返す。1 件でも到達不能なら 503 を返し、kubelet が
+//   Pod を Service の endpoint から外せるようにする。
+//   応答形式は tier1 HealthService の ReadinessResponse（ready / dependencies{reachable,
+//   error_message}）に揃える。
+//
+// timeout:
+//   依存先の hang で probe 自体が kubelet の timeout を超えないよう、各確認に個別の
+//   timeout を被せて並列実行する。
+
+// Package health は BFF の readiness probe を提供する。
+package health
+
+// 標準 import。
+import (
+	// context 伝搬 / timeout。
+	"context"
+	// 応答 JSON。
+	"encoding/json"
+	// HTTP server。
+	"net/http"
+	// 並列実行の待ち合わせ。
+	"sync"
+	// timeout。
+	"time"
+)
+
+// DependencyProbe は BFF が依存する単一の依存先の到達性確認。
+type DependencyProbe struct {
+	// 依存先論理名（応答 dependencies のキー。例 "tier1" / "jwks"）。
+	Name string
+	// 到達性確認関数。ctx 期限内に確認し、到達不能なら non-nil error を返す。
+	Check func(context.Context) error
+}
+
+// DependencyStatus は依存先 1 件の確認結果。
+type DependencyStatus struct {
+	// 到達可能か。
+	Reachable bool `json:"reachable"`
+	// 到達不能時の理由。
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// ReadinessResponse は /readyz の応答 body。
+type ReadinessResponse struct {
+	// 全依存先が到達可能か。
+	Ready bool `json:"ready"`
+	// 依存先ごとの結果。
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Readiness は probes を並列に確認する /readyz ハンドラを返す。
+// timeout は依存先 1 件あたりの確認上限。
+func Readiness(timeout time.Duration, probes ...DependencyProbe) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := check(r.Context(), timeout, probes)
+		status := http.StatusOK
+		if !resp.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		// probe 結果は常に最新を返す（中間 cache に残さない）。
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// check は probes を並列実行して結果を集約する。
+func check(ctx context.Context, timeout time.Duration, probes []DependencyProbe) ReadinessResponse {
+	resp := ReadinessResponse{Ready: true, Dependencies: make(map[string]DependencyStatus, len(probes))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			st := DependencyStatus{Reachable: true}
+			if err := p.Check(probeCtx); err != nil {
+				st = DependencyStatus{ErrorMessage: err.Error()}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Dependencies[p.Name] = st
+			resp.Ready = resp.Ready && st.Reachable
+		}()
+	}
+	wg.Wait()
+	return resp
+}
@@ -0,0 +1,71 @@
+// 本ファイルは readiness probe ハンドラの単体テスト。
+//
+// テスト観点:
+//   - 全依存先が到達可能なら 200 + ready=true
+//   - 1 件でも失敗すれば 503 + 依存先ごとの reachable / error_message
+//   - hang する依存先は timeout で打ち切られ、応答が返る
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serve は /readyz を 1 回呼び、status と復号した body を返す。
+func serve(t *testing.T, h http.Handler) (int, ReadinessResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body ReadinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+func ok(context.Context) error { return nil }
+
+func TestReadiness_AllReachable(t *testing.T) {
+	code, body := serve(t, Readiness(time.Second, DependencyProbe{Name: "tier1", Check: ok}, DependencyProbe{Name: "jwks", Check: ok}))
+	if code != http.StatusOK || !body.Ready {
+		t.Fatalf("status=%d ready=%v", code, body.Ready)
+	}
+	if !body.Dependencies["tier1"].Reachable || !body.Dependencies["jwks"].Reachable {
+		t.Errorf("dependencies = %+v", body.Dependencies)
+	}
+}
+
+func TestReadiness_ReportsFailedDependency(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("connection refused") }
+	code, body := serve(t, Readiness(time.Second, DependencyProbe{Name: "tier1", Check: ok}, DependencyProbe{Name: "jwks", Check: failing}))
+	if code != http.StatusServiceUnavailable || body.Ready {
+		t.Fatalf("status=%d ready=%v, want 503 / false", code, body.Ready)
+	}
+	if !body.Dependencies["tier1"].Reachable {
+		t.Errorf("tier1 should stay reachable: %+v", body.Dependencies["tier1"])
+	}
+	if got := body.Dependencies["jwks"]; got.Reachable || got.ErrorMessage != "connection refused" {
+		t.Errorf("jwks = %+v", got)
+	}
+}
+
+func TestReadiness_TimesOutHangingDependency(t *testing.T) {
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	start := time.Now()
+	code, body := serve(t, Readiness(50*time.Millisecond, DependencyProbe{Name: "tier1", Check: hang}))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("probe took %v, timeout not applied", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || body.Dependencies["tier1"].Reachable {
+		t.Fatalf("status=%d body=%+v", code, body)
+	}
+}
@@ -35,13 +35,13 @@ type AuditEventSummary struct {
 // AuditRecord は監査イベントを記録する。idempotencyKey が空なら毎回新エントリを作る。
 func (c *Client) AuditRecord(ctx context.Context, actor, action, resource, outcome string, attributes map[string]string, idempotencyKey string) (auditID string, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Audit().Record(withTenantFromRequest(ctx), actor, action, resource, outcome, attributes, idempotencyKey)
+	return c.client.Audit().Record(c.callCtx(ctx), actor, action, resource, outcome, attributes, idempotencyKey)
 }
 
 // AuditQuery は監査イベントを範囲検索する。出力は PII Mask 自動適用済。
 func (c *Client) AuditQuery(ctx context.Context, from, to time.Time, filters map[string]string, limit int32) ([]AuditEventSummary, error) {
 	// SDK facade を呼ぶ。
-	events, err := c.client.Audit().Query(withTenantFromRequest(ctx), from, to, filters, limit)
+	events, err := c.client.Audit().Query(c.callCtx(ctx), from, to, filters, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -15,5 +15,5 @@ import (
 // operation は binding component が定義する動詞（例: "create" / "send"）。
 func (c *Client) BindingInvoke(ctx context.Context, name, operation string, data []byte, metadata map[string]string) (responseData []byte, responseMetadata map[string]string, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Binding().Invoke(withTenantFromRequest(ctx), name, operation, data, metadata)
+	return c.client.Binding().Invoke(c.callCtx(ctx), name, operation, data, metadata)
 }
@@ -12,7 +12,7 @@
 //   static cfg.TenantID で全 request を上書きしてしまう越境を防ぐ。
 //
 // ファイル構成:
-//   client.go     — Client struct / コンストラクタ / Close / withTenantFromRequest（本ファイル）
+//   client.go     — Client struct / コンストラクタ / Close / callCtx / withTenantFromRequest（本ファイル）
 //   state.go      — StateGet / StateSave / StateDelete
 //   pubsub.go     — PubSubPublish
 //   secrets.go    — SecretsGet / SecretsRotate
@@ -25,6 +25,8 @@
 //   pii.go        — PiiClassify / PiiMask
 //   feature.go    — FeatureEvaluateBoolean
 //   binding.go    — BindingInvoke
+//   health.go     — CheckReady（/readyz 用の tier1 到達性確認）
+//   tls.go        — transportCredentials（mTLS / 私設 CA / 証明書 hot reload）
 
 // Package k1s0client は tier1 / tier2 への呼出を集約する Infrastructure 層相当。
 package k1s0client
@@ -33,6 +35,8 @@ package k1s0client
 import (
 	// context 伝搬。
 	"context"
+	// 複数 Close エラーの集約。
+	"errors"
 	// エラー整形。
 	"fmt"
 	// timeout。
@@ -40,44 +44,81 @@ import (
 
 	// k1s0 高水準 facade。
 	"github.com/k1s0/sdk-go/k1s0"
+	// readiness 確認用の gRPC 接続。
+	"google.golang.org/grpc"
+	// 標準 gRPC health protocol。
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	// auth middleware の context helpers（per-request tenant_id / subject の解決）。
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/auth"
 	// 設定。
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+	// アクセスログへの upstream 記録。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/shared/reqinfo"
 )
 
 // Client は k1s0 SDK Client の薄いラッパー。
 type Client struct {
 	// SDK Client（接続を保持）。
 	client *k1s0.Client
+	// 接続先 target（アクセスログの upstream）。
+	target string
+	// readiness 確認用の接続（SDK Client は接続を公開しないため別に持つ）。
+	healthConn *grpc.ClientConn
+	// 標準 gRPC health client。
+	health healthpb.HealthClient
 }
 
 // New は config から Client を組み立てる。
 func New(ctx context.Context, cfg config.K1s0Config) (*Client, error) {
+	// transport credentials は BFF 側で組み、mTLS / 私設 CA を SDK に渡す。
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("k1s0client.New: %w", err)
+	}
 	// dial timeout を 10 秒で被せる。
 	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	// SDK Client を初期化する。
+	// SDK Client を初期化する（DialOptions の credentials が SDK 既定を後勝ちで上書きする）。
 	c, err := k1s0.New(dialCtx, k1s0.Config{
-		Target:   cfg.Target,
-		TenantID: cfg.TenantID,
-		Subject:  cfg.Subject,
-		UseTLS:   cfg.UseTLS,
+		Target:      cfg.Target,
+		TenantID:    cfg.TenantID,
+		Subject:     cfg.Subject,
+		UseTLS:      cfg.UseTLS,
+		DialOptions: []grpc.DialOption{grpc.WithTransportCredentials(creds)},
 	})
 	// 失敗時はラップして伝搬する。
 	if err != nil {
 		return nil, fmt.Errorf("k1s0client.New: failed to dial %s: %w", cfg.Target, err)
 	}
-	return &Client{client: c}, nil
+	// readiness 確認用の接続も同じ credentials で張る。
+	healthConn, err := grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("k1s0client.New: failed to dial health %s: %w", cfg.Target, err)
+	}
+	return &Client{client: c, target: cfg.Target, healthConn: healthConn, health: healthpb.NewHealthClient(healthConn)}, nil
 }
 
-// Close は SDK Client を解放する。
+// Close は SDK Client と readiness 確認用の接続を解放する。
 func (c *Client) Close() error {
-	if c == nil || c.client == nil {
+	if c == nil {
 		return nil
 	}
-	return c.client.Close()
+	var errs []error
+	if c.client != nil {
+		errs = append(errs, c.client.Close())
+	}
+	if c.healthConn != nil {
+		errs = append(errs, c.healthConn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// callCtx は SDK 呼出用の ctx を返す。per-request tenant を伝搬し、アクセスログに upstream を記録する。
+func (c *Client) callCtx(ctx context.Context) context.Context {
+	reqinfo.SetUpstream(ctx, c.target)
+	return withTenantFromRequest(ctx)
 }
 
 // withTenantFromRequest は auth middleware が attach した tenant_id / subject を
@@ -8,14 +8,24 @@
 //     k1s0.WithTenant で SDK ctx に伝搬する（NFR-E-AC-003 違反防止の中核ロジック）。
 //   - middleware 未経由 ctx は素通り（cfg.TenantID にフォールバック）。
 //   - Close() は nil-safe。
+//   - callCtx: アクセスログに実際の upstream を記録する。
+//   - CheckReady: tier1 の標準 gRPC health が SERVING のときだけ nil。
 
 package k1s0client
 
 import (
 	"context"
+	"net"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/k1s0/k1s0/src/tier3/bff/internal/auth"
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/shared/reqinfo"
 )
 
 // authCtxWith は auth middleware が attach する 3 つの context value を直接 set した
@@ -66,6 +76,19 @@ func TestWithTenantFromRequest_EmptyTenantFallsBack(t *testing.T) {
 	}
 }
 
+func TestCallCtx_RecordsUpstream(t *testing.T) {
+	// アクセスログの upstream は実際に tier1 を呼んだ要求にだけ記録される。
+	ctx, info := reqinfo.WithInfo(authCtxWith("T-PROD", "alice", "jwt"))
+	c := &Client{target: "tier1:50001"}
+	out := c.callCtx(ctx)
+	if info.Upstream() != "tier1:50001" {
+		t.Errorf("upstream = %q", info.Upstream())
+	}
+	if got := auth.TenantIDFromContext(out); got != "T-PROD" {
+		t.Errorf("tenant_id should be preserved through, got %q", got)
+	}
+}
+
 func TestClose_NilSafe(t *testing.T) {
 	// nil receiver / nil client いずれも panic しない。
 	var c *Client
@@ -77,3 +100,44 @@ func TestClose_NilSafe(t *testing.T) {
 		t.Errorf("Client with nil client.Close should be no-op, got %v", err)
 	}
 }
+
+// startHealthServer は status を返す標準 gRPC health server を起動し、接続済 Client を返す。
+func startHealthServer(t *testing.T, status healthpb.HealthCheckResponse_ServingStatus) *Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("", status)
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	creds, err := transportCredentials(config.K1s0Config{})
+	if err != nil {
+		t.Fatalf("credentials: %v", err)
+	}
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	c := &Client{healthConn: conn, health: healthpb.NewHealthClient(conn)}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestCheckReady(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := startHealthServer(t, healthpb.HealthCheckResponse_SERVING).CheckReady(ctx); err != nil {
+		t.Errorf("SERVING should be ready, got %v", err)
+	}
+	if err := startHealthServer(t, healthpb.HealthCheckResponse_NOT_SERVING).CheckReady(ctx); err == nil {
+		t.Errorf("NOT_SERVING should not be ready")
+	}
+	var c *Client
+	if err := c.CheckReady(ctx); err == nil {
+		t.Errorf("nil Client should not be ready")
+	}
+}
@@ -15,5 +15,5 @@ import (
 // inputJSON は評価コンテキスト、includeTrace=true なら traceJSON にトレース情報が入る。
 func (c *Client) DecisionEvaluate(ctx context.Context, ruleID, ruleVersion string, inputJSON []byte, includeTrace bool) (outputJSON, traceJSON []byte, elapsedUs int64, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Decision().Evaluate(withTenantFromRequest(ctx), ruleID, ruleVersion, inputJSON, includeTrace)
+	return c.client.Decision().Evaluate(c.callCtx(ctx), ruleID, ruleVersion, inputJSON, includeTrace)
 }
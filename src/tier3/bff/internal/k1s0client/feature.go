@@ -16,5 +16,5 @@ import (
 // 戻り値の variant は flag バリアント名、reason は flagd の評価理由（TARGETING_MATCH 等）。
 func (c *Client) FeatureEvaluateBoolean(ctx context.Context, flagKey string, evalCtx map[string]string) (value bool, variant, reason string, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Feature().EvaluateBoolean(withTenantFromRequest(ctx), flagKey, evalCtx)
+	return c.client.Feature().EvaluateBoolean(c.callCtx(ctx), flagKey, evalCtx)
 }
@@ -0,0 +1,37 @@
+// k1s0 tier1 到達性確認。
+//
+// /readyz から呼び、tier1 facade の標準 gRPC health（grpc.health.v1）を確認する。
+// tier1 は service 名 "" で Pod 全体の status を公開している（internal/common/runtime.go）。
+
+package k1s0client
+
+// 標準 / 外部 import。
+import (
+	// context 伝搬。
+	"context"
+	// 未接続エラー。
+	"errors"
+	// エラー整形。
+	"fmt"
+
+	// 標準 gRPC health protocol。
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// CheckReady は tier1 facade が SERVING かを確認する。
+// SERVING 以外の応答や到達不能は error を返す。timeout は呼出側の ctx で与える。
+func (c *Client) CheckReady(ctx context.Context) error {
+	// 未接続（test 経路等）は到達不能扱い。
+	if c == nil || c.health == nil {
+		return errors.New("k1s0client: not connected")
+	}
+	// service 名 "" は Pod 全体の status。
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("tier1 health check: %w", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("tier1 health check: status %s", resp.GetStatus())
+	}
+	return nil
+}
@@ -15,5 +15,5 @@ import (
 // timeoutMs=0 なら SDK 既定のデッドラインに従う。
 func (c *Client) InvokeCall(ctx context.Context, appID, method string, data []byte, contentType string, timeoutMs int32) (responseData []byte, responseContentType string, status int32, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Invoke().Call(withTenantFromRequest(ctx), appID, method, data, contentType, timeoutMs)
+	return c.client.Invoke().Call(c.callCtx(ctx), appID, method, data, contentType, timeoutMs)
 }
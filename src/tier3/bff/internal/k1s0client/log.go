@@ -58,5 +58,5 @@ func toSDKSeverity(s LogSeverity) k1s0.Severity {
 // LogSend は単一エントリ送信。
 func (c *Client) LogSend(ctx context.Context, severity LogSeverity, body string, attributes map[string]string) error {
 	// SDK facade を呼ぶ。
-	return c.client.Log().Send(withTenantFromRequest(ctx), toSDKSeverity(severity), body, attributes)
+	return c.client.Log().Send(c.callCtx(ctx), toSDKSeverity(severity), body, attributes)
 }
@@ -26,7 +26,7 @@ type PiiFindingSummary struct {
 // PiiClassify はテキスト中の PII を分類する（マスクはせず、検出のみ）。
 func (c *Client) PiiClassify(ctx context.Context, text string) (findings []PiiFindingSummary, containsPii bool, err error) {
 	// SDK facade を呼ぶ。
-	raw, contains, err := c.client.Pii().Classify(withTenantFromRequest(ctx), text)
+	raw, contains, err := c.client.Pii().Classify(c.callCtx(ctx), text)
 	if err != nil {
 		return nil, false, err
 	}
@@ -46,7 +46,7 @@ func (c *Client) PiiClassify(ctx context.Context, text string) (findings []PiiFi
 // PiiMask はテキスト中の PII をマスクして返す。findings には検出位置も入る。
 func (c *Client) PiiMask(ctx context.Context, text string) (maskedText string, findings []PiiFindingSummary, err error) {
 	// SDK facade を呼ぶ。
-	masked, raw, err := c.client.Pii().Mask(withTenantFromRequest(ctx), text)
+	masked, raw, err := c.client.Pii().Mask(c.callCtx(ctx), text)
 	if err != nil {
 		return "", nil, err
 	}
@@ -28,5 +28,5 @@ func (c *Client) PubSubPublish(ctx context.Context, topic string, data []byte, c
 		opts = append(opts, k1s0.WithMetadata(metadata))
 	}
 	// SDK facade を呼ぶ。
-	return c.client.PubSub().Publish(withTenantFromRequest(ctx), topic, data, contentType, opts...)
+	return c.client.PubSub().Publish(c.callCtx(ctx), topic, data, contentType, opts...)
 }
@@ -18,7 +18,7 @@ import (
 // 戻り値の values は key/value マップ（VAULT 互換）、version は最新版番号。
 func (c *Client) SecretsGet(ctx context.Context, name string) (values map[string]string, version int32, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Secrets().Get(withTenantFromRequest(ctx), name)
+	return c.client.Secrets().Get(c.callCtx(ctx), name)
 }
 
 // SecretsRotate は指定 secret をローテートする。
@@ -35,5 +35,5 @@ func (c *Client) SecretsRotate(ctx context.Context, name string, gracePeriodSec
 		opts = append(opts, k1s0.WithIdempotencyKeyRotate(idempotencyKey))
 	}
 	// SDK facade を呼ぶ。
-	return c.client.Secrets().Rotate(withTenantFromRequest(ctx), name, opts...)
+	return c.client.Secrets().Rotate(c.callCtx(ctx), name, opts...)
 }
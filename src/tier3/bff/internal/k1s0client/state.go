@@ -15,14 +15,14 @@ import (
 // auth middleware の tenant_id を SDK へ伝搬する。
 func (c *Client) StateGet(ctx context.Context, store, key string) (data []byte, etag string, found bool, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.State().Get(withTenantFromRequest(ctx), store, key)
+	return c.client.State().Get(c.callCtx(ctx), store, key)
 }
 
 // StateSave は k1s0 State にキーを保存する。
 // auth middleware の tenant_id を SDK へ伝搬する。
 func (c *Client) StateSave(ctx context.Context, store, key string, data []byte) (string, error) {
 	// SDK facade を呼ぶ。
-	return c.client.State().Save(withTenantFromRequest(ctx), store, key, data)
+	return c.client.State().Save(c.callCtx(ctx), store, key, data)
 }
 
 // StateDelete は k1s0 State から指定キーを削除する。
@@ -30,5 +30,5 @@ func (c *Client) StateSave(ctx context.Context, store, key string, data []byte)
 // auth middleware の tenant_id を SDK へ伝搬する。
 func (c *Client) StateDelete(ctx context.Context, store, key, expectedEtag string) error {
 	// SDK facade を呼ぶ。
-	return c.client.State().Delete(withTenantFromRequest(ctx), store, key, expectedEtag)
+	return c.client.State().Delete(c.callCtx(ctx), store, key, expectedEtag)
 }
@@ -57,5 +57,5 @@ func (c *Client) TelemetryEmitMetric(ctx context.Context, points []MetricPoint)
 		})
 	}
 	// SDK facade を呼ぶ。
-	return c.client.Telemetry().EmitMetric(withTenantFromRequest(ctx), metrics)
+	return c.client.Telemetry().EmitMetric(c.callCtx(ctx), metrics)
 }
@@ -0,0 +1,185 @@
+// k1s0 tier1 接続の TLS / mTLS 設定。
+//
+// 役割:
+//   zero-trust 内部網向けに、tier1 への gRPC 接続へクライアント証明書（mTLS）と私設 CA bundle を
+//   適用する。SDK は UseTLS=true で TLS 1.3 + システム CA の credentials を組むが、
+//   k1s0.Config.DialOptions の WithTransportCredentials が後勝ちで上書きするため、
+//   SDK を変更せず BFF 側で credentials を差し替えられる。
+//
+// hot reload:
+//   Vault Agent / cert-manager は証明書を同じパスへ書き換えて更新する。GetClientCertificate で
+//   handshake ごとに cert / key ファイルの更新時刻を確認し、変化していれば読み直す。読み直しに
+//   失敗した場合（書換途中等）は直前の有効な証明書を使い続ける。CA bundle も同様に、VerifyConnection で
+//   handshake ごとに更新時刻を確認して読み直す（標準検証は起動時の RootCAs に固定されるため自前で検証する）。
+
+package k1s0client
+
+// 標準 / 外部 import。
+import (
+	// TLS 設定。
+	"crypto/tls"
+	// CA bundle。
+	"crypto/x509"
+	// エラー生成。
+	"errors"
+	// エラー整形。
+	"fmt"
+	// 証明書ファイル。
+	"os"
+	// reload の排他。
+	"sync"
+	// 更新時刻。
+	"time"
+
+	// gRPC 認証情報。
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	// 設定。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// transportCredentials は cfg に応じた gRPC transport credentials を返す。
+// TLS 無効時は平文、TLS 有効時は TLS 1.3 に cfg の CA bundle / クライアント証明書を適用する。
+func transportCredentials(cfg config.K1s0Config) (credentials.TransportCredentials, error) {
+	// dev: 平文。
+	if !cfg.UseTLS {
+		return insecure.NewCredentials(), nil
+	}
+	tlsCfg, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// clientTLSConfig は cfg から tls.Config を組み立てる。
+func clientTLSConfig(cfg config.K1s0Config) (*tls.Config, error) {
+	// SDK と同じ TLS 1.3 strict を基準にする。
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS13, ServerName: cfg.TLSServerName}
+	// 私設 CA（指定時はシステム CA を使わない。起動時に 1 度読み、以後は更新時に読み直す）。
+	if cfg.TLSCAFile != "" {
+		r := &caReloader{caFile: cfg.TLSCAFile}
+		if err := r.reload(); err != nil {
+			return nil, err
+		}
+		// 標準検証を止め、VerifyConnection で最新の CA pool による同等の検証を行う。
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyConnection = r.verifyConnection
+	}
+	// mTLS のクライアント証明書（起動時に 1 度読み、以後は更新時に読み直す）。
+	if cfg.TLSCertFile != "" {
+		r := &certReloader{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile}
+		if err := r.reload(); err != nil {
+			return nil, err
+		}
+		tlsCfg.GetClientCertificate = r.getClientCertificate
+	}
+	return tlsCfg, nil
+}
+
+// certReloader はファイル更新時にクライアント証明書を読み直す。
+type certReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.Mutex
+	// 直前に読み込めた証明書と、その時点の更新時刻。
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// getClientCertificate は tls.Config.GetClientCertificate 用。更新を検出したら読み直す。
+func (r *certReloader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	// 読み直しに失敗しても直前の証明書で接続を続ける（次の handshake で再試行）。
+	_ = r.reload()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cert, nil
+}
+
+// reload は cert / key の更新時刻が変わっていれば key pair を読み直す。
+func (r *certReloader) reload() error {
+	mod, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("k1s0client: stat client certificate: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && mod.Equal(r.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("k1s0client: load client certificate: %w", err)
+	}
+	r.cert, r.modTime = &cert, mod
+	return nil
+}
+
+// caReloader はファイル更新時に CA bundle を読み直す。
+type caReloader struct {
+	caFile string
+	mu     sync.Mutex
+	// 直前に読み込めた CA pool と、その時点の更新時刻。
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// verifyConnection は tls.Config.VerifyConnection 用。最新の CA pool でサーバ証明書と host 名を検証する。
+func (r *caReloader) verifyConnection(cs tls.ConnectionState) error {
+	// 読み直しに失敗しても直前の pool で検証を続ける（次の handshake で再試行）。
+	_ = r.reload()
+	r.mu.Lock()
+	pool := r.pool
+	r.mu.Unlock()
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("k1s0client: server presented no certificate")
+	}
+	// 標準検証と同じく中間証明書は peer の提示分を使う。
+	opts := x509.VerifyOptions{Roots: pool, DNSName: cs.ServerName, Intermediates: x509.NewCertPool()}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("k1s0client: verify server certificate: %w", err)
+	}
+	return nil
+}
+
+// reload は CA bundle の更新時刻が変わっていれば pool を読み直す。
+func (r *caReloader) reload() error {
+	mod, err := latestModTime(r.caFile)
+	if err != nil {
+		return fmt.Errorf("k1s0client: stat ca bundle: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pool != nil && mod.Equal(r.modTime) {
+		return nil
+	}
+	pem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return fmt.Errorf("k1s0client: read ca bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("k1s0client: no certificates in ca bundle %s", r.caFile)
+	}
+	r.pool, r.modTime = pool, mod
+	return nil
+}
+
+// latestModTime は files の更新時刻のうち最新を返す。
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, f := range files {
+		st, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+	return latest, nil
+}
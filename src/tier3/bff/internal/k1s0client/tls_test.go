@@ -0,0 +1,243 @@
+// 本ファイルは tier1 接続の TLS / mTLS 設定の単体テスト。
+//
+// テスト観点:
+//   - 私設 CA + クライアント証明書で mTLS 必須の gRPC server に接続できる
+//   - cert / key ファイルの更新を次の handshake で読み直し、壊れた更新では直前の証明書を使い続ける
+//   - CA bundle の更新も次の handshake で読み直し、壊れた更新では直前の pool で検証を続ける
+//   - CA bundle / 証明書の読込失敗は起動時 error
+
+package k1s0client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// testCA は test 用の私設 CA。
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCA は自己署名 CA を作る。
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ca key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "test-ca"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("ca cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue は CA で署名した leaf 証明書と秘密鍵の PEM を返す。
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("leaf key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial, Subject: pkix.Name{CommonName: cn}, DNSNames: []string{cn},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour),
+		KeyUsage: x509.KeyUsageDigitalSignature, ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("leaf cert: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile は dir/name に data を書き、更新時刻を mod にする。
+func writeFile(t *testing.T, dir, name string, data []byte, mod time.Time) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	if err := os.Chtimes(p, mod, mod); err != nil {
+		t.Fatalf("chtimes %s: %v", name, err)
+	}
+	return p
+}
+
+// leafCN は tls.Certificate の leaf の CN を返す。
+func leafCN(t *testing.T, c *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	if err != nil {
+		t.Fatalf("parse leaf: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestTransportCredentials_MutualTLS(t *testing.T) {
+	ca, dir := newTestCA(t), t.TempDir()
+	now := time.Now()
+	srvCert, srvKey := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	serverPair, err := tls.X509KeyPair(srvCert, srvKey)
+	if err != nil {
+		t.Fatalf("server pair: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+	// クライアント証明書必須の tier1 相当 server。
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverPair}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert, MinVersion: tls.VersionTLS13,
+	})))
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cliCert, cliKey := ca.issue(t, "portal-bff", x509.ExtKeyUsageClientAuth)
+	creds, err := transportCredentials(config.K1s0Config{
+		UseTLS:        true,
+		TLSCAFile:     writeFile(t, dir, "ca.crt", ca.pem, now),
+		TLSCertFile:   writeFile(t, dir, "tls.crt", cliCert, now),
+		TLSKeyFile:    writeFile(t, dir, "tls.key", cliKey, now),
+		TLSServerName: "localhost",
+	})
+	if err != nil {
+		t.Fatalf("credentials: %v", err)
+	}
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	c := &Client{healthConn: conn, health: healthpb.NewHealthClient(conn)}
+	t.Cleanup(func() { _ = c.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.CheckReady(ctx); err != nil {
+		t.Fatalf("mTLS health check: %v", err)
+	}
+}
+
+func TestCertReloader_ReloadsOnChange(t *testing.T) {
+	ca, dir := newTestCA(t), t.TempDir()
+	t0 := time.Now().Add(-time.Minute)
+	cert1, key1 := ca.issue(t, "gen-1", x509.ExtKeyUsageClientAuth)
+	tlsCfg, err := clientTLSConfig(config.K1s0Config{
+		UseTLS:      true,
+		TLSCertFile: writeFile(t, dir, "tls.crt", cert1, t0),
+		TLSKeyFile:  writeFile(t, dir, "tls.key", key1, t0),
+	})
+	if err != nil {
+		t.Fatalf("tls config: %v", err)
+	}
+	get := func() string {
+		c, err := tlsCfg.GetClientCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetClientCertificate: %v", err)
+		}
+		return leafCN(t, c)
+	}
+	if got := get(); got != "gen-1" {
+		t.Fatalf("initial cert = %s", got)
+	}
+	// ローテーション後は次の handshake で新しい証明書を使う。
+	cert2, key2 := ca.issue(t, "gen-2", x509.ExtKeyUsageClientAuth)
+	writeFile(t, dir, "tls.crt", cert2, t0.Add(time.Second))
+	writeFile(t, dir, "tls.key", key2, t0.Add(time.Second))
+	if got := get(); got != "gen-2" {
+		t.Fatalf("rotated cert = %s, want gen-2", got)
+	}
+	// 壊れた更新（書換途中等）では直前の証明書を使い続ける。
+	writeFile(t, dir, "tls.crt", []byte("broken"), t0.Add(2*time.Second))
+	if got := get(); got != "gen-2" {
+		t.Fatalf("broken update should keep gen-2, got %s", got)
+	}
+}
+
+func TestCAReloader_ReloadsOnChange(t *testing.T) {
+	ca1, ca2, dir := newTestCA(t), newTestCA(t), t.TempDir()
+	t0 := time.Now().Add(-time.Minute)
+	tlsCfg, err := clientTLSConfig(config.K1s0Config{UseTLS: true, TLSCAFile: writeFile(t, dir, "ca.crt", ca1.pem, t0)})
+	if err != nil {
+		t.Fatalf("tls config: %v", err)
+	}
+	// ca の発行したサーバ証明書を peer として検証する。
+	verify := func(ca *testCA, serverName string) error {
+		certPEM, _ := ca.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+		block, _ := pem.Decode(certPEM)
+		leaf, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("parse leaf: %v", err)
+		}
+		return tlsCfg.VerifyConnection(tls.ConnectionState{ServerName: serverName, PeerCertificates: []*x509.Certificate{leaf}})
+	}
+	if err := verify(ca1, "localhost"); err != nil {
+		t.Fatalf("ca1 server: %v", err)
+	}
+	if err := verify(ca1, "other"); err == nil {
+		t.Fatalf("host name mismatch should fail")
+	}
+	if err := verify(ca2, "localhost"); err == nil {
+		t.Fatalf("ca2 server should fail before rotation")
+	}
+	// ローテーション後は次の handshake で新しい CA を使う。
+	writeFile(t, dir, "ca.crt", ca2.pem, t0.Add(time.Second))
+	if err := verify(ca2, "localhost"); err != nil {
+		t.Fatalf("ca2 server after rotation: %v", err)
+	}
+	// 壊れた更新（書換途中等）では直前の pool で検証を続ける。
+	writeFile(t, dir, "ca.crt", []byte("broken"), t0.Add(2*time.Second))
+	if err := verify(ca2, "localhost"); err != nil {
+		t.Fatalf("broken update should keep ca2: %v", err)
+	}
+}
+
+func TestClientTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := clientTLSConfig(config.K1s0Config{UseTLS: true, TLSCAFile: filepath.Join(dir, "missing.crt")}); err == nil {
+		t.Errorf("missing ca bundle should error")
+	}
+	if _, err := clientTLSConfig(config.K1s0Config{UseTLS: true, TLSCAFile: writeFile(t, dir, "ca.crt", []byte("x"), time.Now())}); err == nil {
+		t.Errorf("ca bundle without certificates should error")
+	}
+	bad := writeFile(t, dir, "tls.crt", []byte("x"), time.Now())
+	if _, err := clientTLSConfig(config.K1s0Config{UseTLS: true, TLSCertFile: bad, TLSKeyFile: bad}); err == nil {
+		t.Errorf("invalid client certificate should error")
+	}
+}
@@ -16,5 +16,5 @@ import (
 // idempotent=true で同 workflowID 再投入を冪等化する（Dapr / Temporal バックエンド両対応）。
 func (c *Client) WorkflowStart(ctx context.Context, workflowType, workflowID string, input []byte, idempotent bool) (returnedID, runID string, err error) {
 	// SDK facade を呼ぶ。
-	return c.client.Workflow().Start(withTenantFromRequest(ctx), workflowType, workflowID, input, idempotent)
+	return c.client.Workflow().Start(c.callCtx(ctx), workflowType, workflowID, input, idempotent)
 }
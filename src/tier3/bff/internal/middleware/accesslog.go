@@ -0,0 +1,153 @@
+// アクセスログ middleware。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/04_bff配置.md
+//
+// 役割:
+//   1 要求につき 1 件の slog JSON レコード（method / path / status / latency / sub /
+//   trace_id / upstream / bytes）を出力する。mux 全体の最外周に被せ、CORS preflight や
+//   auth による 401 / 403 も記録対象にする。
+//
+// sub / upstream の取得:
+//   auth middleware は内側で request context を差し替えるため、外側から sub を読めない。
+//   そこで本 middleware が reqinfo.Info を context に載せ、auth が sub を、k1s0client が
+//   実際に呼んだ tier1 target を書き込む。tier1 を呼ばなかった要求（401 / preflight 等）の
+//   upstream は空になる。
+//
+// サンプリング:
+//   SampleRate で成功応答を間引く。5xx は障害調査に必須なため常に出力する。
+
+package middleware
+
+// 標準 / 内部 import。
+import (
+	// 構造化ログ。
+	"log/slog"
+	// サンプリング用乱数。
+	"math/rand/v2"
+	// HTTP server。
+	"net/http"
+	// 文字列処理。
+	"strings"
+	// latency 計測。
+	"time"
+
+	// アクセスログ設定。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+	// 内側が書き込む sub / upstream。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/shared/reqinfo"
+)
+
+// AccessLog は cfg に従ってアクセスログを出力する middleware を返す。
+func AccessLog(cfg config.AccessLogConfig, logger *slog.Logger) func(http.Handler) http.Handler {
+	// 無効時は next をそのまま返す。
+	if !cfg.Enabled || logger == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	// 除外パスは完全一致で判定する。
+	excluded := make(map[string]struct{}, len(cfg.ExcludePaths))
+	for _, p := range cfg.ExcludePaths {
+		excluded[p] = struct{}{}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// healthz / metrics 等はログを出さない。
+			if _, skip := excluded[r.URL.Path]; skip {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			ctx, info := reqinfo.WithInfo(r.Context())
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+			// WriteHeader を呼ばずに返った handler は 200 扱い。
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			// 5xx 以外はサンプリング率で間引く。
+			if status < http.StatusInternalServerError && !sampled(cfg.SampleRate) {
+				return
+			}
+			logger.LogAttrs(r.Context(), levelFor(status), "http access",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("latency_ms", time.Since(start).Milliseconds()),
+				slog.String("sub", info.Subject()),
+				slog.String("trace_id", traceIDFromHeader(r.Header.Get("traceparent"))),
+				slog.String("upstream", info.Upstream()),
+				slog.Int64("bytes", sw.bytes),
+			)
+		})
+	}
+}
+
+// sampled は rate（0.0〜1.0）の確率で true を返す。
+func sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// levelFor は status からログレベルを決める。
+func levelFor(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// traceIDFromHeader は W3C traceparent（version-traceid-parentid-flags）から trace-id 部を取り出す。
+// 形式不正の場合は空文字を返す。
+func traceIDFromHeader(tp string) string {
+	parts := strings.Split(strings.TrimSpace(tp), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	// 16 進以外を含む / all-zero の trace-id は無効値として扱う。
+	if strings.Trim(parts[1], "0123456789abcdef") != "" || strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// statusWriter は status code と書込バイト数を記録する ResponseWriter ラッパ。
+type statusWriter struct {
+	http.ResponseWriter
+	// 書き込まれた status（0 は未書込）。
+	status int
+	// 書き込まれた body バイト数。
+	bytes int64
+}
+
+// WriteHeader は status を記録してから委譲する。
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write は暗黙の 200 と書込バイト数を記録してから委譲する。
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap は http.ResponseController が下層の Flush 等に到達できるようにする。
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,126 @@
+// 本ファイルはアクセスログ middleware の単体テスト。
+//
+// テスト観点:
+//   - 1 要求 1 レコードで method / path / status / sub / trace_id / upstream / bytes を出力する
+//   - 除外パスは出力しない
+//   - SampleRate=0 でも 5xx は出力する
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/shared/reqinfo"
+)
+
+// newTestLogger は buf に JSON を書き出す slog.Logger を返す。
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// decodeLines は buf の JSON Lines を map のスライスに分解する。
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		m := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestAccessLog_EmitsOneRecord(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.AccessLogConfig{Enabled: true, SampleRate: 1}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqinfo.SetSubject(r.Context(), "user-1")
+		reqinfo.SetUpstream(r.Context(), "tier1:50001")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+	h := AccessLog(cfg, newTestLogger(&buf))(next)
+	req := httptest.NewRequest(http.MethodPost, "/api/state/save", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("want 1 record, got %d", len(lines))
+	}
+	got := lines[0]
+	want := map[string]any{
+		"method":   "POST",
+		"path":     "/api/state/save",
+		"status":   float64(201),
+		"sub":      "user-1",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"upstream": "tier1:50001",
+		"bytes":    float64(5),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["latency_ms"]; !ok {
+		t.Errorf("latency_ms missing")
+	}
+}
+
+func TestAccessLog_ExcludedPath(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.AccessLogConfig{Enabled: true, SampleRate: 1, ExcludePaths: []string{"/healthz"}}
+	h := AccessLog(cfg, newTestLogger(&buf))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("excluded path should not be logged: %s", buf.String())
+	}
+}
+
+func TestAccessLog_ServerErrorsBypassSampling(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := config.AccessLogConfig{Enabled: true, SampleRate: 0}
+	status := http.StatusOK
+	h := AccessLog(cfg, newTestLogger(&buf))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("2xx should be sampled out at rate 0")
+	}
+	status = http.StatusBadGateway
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	lines := decodeLines(t, &buf)
+	if len(lines) != 1 || lines[0]["level"] != "ERROR" {
+		t.Fatalf("5xx should always be logged at ERROR, got %v", lines)
+	}
+}
+
+func TestTraceIDFromHeader_RejectsInvalid(t *testing.T) {
+	for _, tp := range []string{
+		"",
+		"garbage",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f35-00f067aa0ba902b7-01",
+	} {
+		if got := traceIDFromHeader(tp); got != "" {
+			t.Errorf("traceIDFromHeader(%q) = %q, want empty", tp, got)
+		}
+	}
+}
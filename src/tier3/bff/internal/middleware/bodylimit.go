@@ -0,0 +1,106 @@
+// リクエスト body サイズ制限 / slow-client 対策 middleware。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/04_bff配置.md
+//
+// 役割:
+//   - Content-Length が上限を超える要求は handler を呼ばず 413 で即時拒否する
+//   - chunked 等で Content-Length が無い要求は http.MaxBytesReader で読込量を制限し、
+//     超過時は handler 側の decode が *http.MaxBytesError を受け取る（rest / graphql が 413 に変換）
+//   - body 読込に要求単位の read deadline を設定し、遅い client が接続を占有し続けるのを防ぐ
+//     （header 読込は http.Server.ReadHeaderTimeout が担う）
+//   - deadline は body を持つ要求にだけ設定し、body の EOF / 読込エラー / Close で解除する。
+//     残したままだと net/http の background read が timeout し、長い handler の r.Context() が cancel される
+
+package middleware
+
+// 標準 / 内部 import。
+import (
+	// JSON エンコード。
+	"encoding/json"
+	// body wrapper。
+	"io"
+	// HTTP server。
+	"net/http"
+	// deadline 解除の一回化。
+	"sync"
+	// deadline 計算。
+	"time"
+
+	// HTTP 設定。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// BodyLimit は cfg.MaxBodyBytes / cfg.BodyReadTimeoutSec に従って body 読込を制限する middleware を返す。
+// 両方とも 0 以下なら pass-through を返す。
+func BodyLimit(cfg config.HTTPConfig) func(http.Handler) http.Handler {
+	maxBytes := cfg.MaxBodyBytes
+	readTimeout := time.Duration(cfg.BodyReadTimeoutSec) * time.Second
+	// 無効時は next をそのまま返す。
+	if maxBytes <= 0 && readTimeout <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxBytes > 0 {
+				// 申告サイズで超過が確定している要求は読まずに拒否する。
+				if r.ContentLength > maxBytes {
+					writeTooLarge(w)
+					return
+				}
+				// 申告の無い / 偽った要求は読込量で打ち切る。
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			// body の無い要求（GET 等）には deadline を掛けない。
+			if readTimeout > 0 && (r.ContentLength != 0 || len(r.TransferEncoding) > 0) {
+				rc := http.NewResponseController(w)
+				// ResponseWriter が deadline 非対応（test recorder 等）の場合は無視する。
+				if rc.SetReadDeadline(time.Now().Add(readTimeout)) == nil {
+					// 読み終えたら解除し、handler 実行中の接続監視に deadline を残さない。
+					r.Body = &deadlineBody{ReadCloser: r.Body, rc: rc}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deadlineBody は body の読込終了時に接続の read deadline を解除する io.ReadCloser。
+type deadlineBody struct {
+	io.ReadCloser
+	rc   *http.ResponseController
+	once sync.Once
+}
+
+// Read は下位 body を読み、EOF / エラーで deadline を解除する。
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	// 読込終了（EOF を含む）で解除する。
+	if err != nil {
+		b.clear()
+	}
+	return n, err
+}
+
+// Close は deadline を解除してから下位 body を閉じる。
+func (b *deadlineBody) Close() error {
+	b.clear()
+	return b.ReadCloser.Close()
+}
+
+// clear は read deadline を一度だけ解除する。
+func (b *deadlineBody) clear() {
+	b.once.Do(func() { _ = b.rc.SetReadDeadline(time.Time{}) })
+}
+
+// writeTooLarge は 413 + エラー JSON を返す。
+func writeTooLarge(w http.ResponseWriter) {
+	// 以降の body を読まずに接続を閉じるよう client に伝える。
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"code":    "E-T3-BFF-BODY-TOO-LARGE",
+		"message": "request body too large",
+	})
+}
@@ -0,0 +1,132 @@
+// CORS middleware。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/04_bff配置.md
+//
+// 役割:
+//   SPA を BFF と別 origin に配置する構成向けに、config.CORSConfig に従って
+//   Access-Control-* ヘッダを付与する。auth middleware より外側（mux 全体）に被せ、
+//   Authorization ヘッダを持たない preflight (OPTIONS) が 401 にならないようにする。
+//
+// origin 照合:
+//   - "*"                      : 全 origin を許可（credentials とは併用不可、config で検証済）
+//   - "https://app.example.com": 完全一致
+//   - "https://*.example.com"  : scheme 一致かつ任意のサブドメイン（apex は含まない）
+
+// Package middleware は portal-bff / admin-bff 共通の HTTP middleware を提供する。
+package middleware
+
+// 標準 / 内部 import。
+import (
+	// HTTP server。
+	"net/http"
+	// 数値 → 文字列変換。
+	"strconv"
+	// 文字列処理。
+	"strings"
+
+	// CORS 設定。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// CORS は cfg に従って cross-origin 要求を処理する middleware を返す。
+// AllowedOrigins が空なら何もしない pass-through を返す。
+func CORS(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	// 無効時は next をそのまま返す。
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	// preflight 応答ヘッダは起動時に一度だけ組み立てる。
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAgeSec > 0 {
+		maxAge = strconv.Itoa(cfg.MaxAgeSec)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			// 同一 origin / 非ブラウザ要求は CORS の対象外。
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// 応答が Origin によって変わることを中間キャッシュに伝える。
+			w.Header().Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			allowed, wildcard := matchOrigin(cfg.AllowedOrigins, origin)
+			if !allowed {
+				// 不許可 origin の preflight はここで打ち切る（後段の auth に到達させない）。
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				// 実要求はヘッダを付けずに通す（ブラウザが応答の読み取りを拒否する）。
+				next.ServeHTTP(w, r)
+				return
+			}
+			// "*" 許可かつ credentials 無しなら origin を反射せず "*" を返す。
+			if wildcard && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			// 実要求は後段へ委譲する。
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// preflight は後段に渡さず 204 で応答する。
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if methods != "" {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+			}
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if maxAge != "" {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// matchOrigin は origin が許可リストに含まれるかを判定する。
+// 2 つ目の戻り値は "*" による許可だったかどうか。
+func matchOrigin(allowed []string, origin string) (bool, bool) {
+	for _, a := range allowed {
+		switch {
+		case a == "*":
+			return true, true
+		case strings.EqualFold(a, origin):
+			return true, false
+		case matchWildcardSubdomain(a, origin):
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// matchWildcardSubdomain は "scheme://*.domain[:port]" パターンと origin を照合する。
+// "*." の直前までの scheme と、"*" 以降の suffix が一致し、かつ label が 1 つ以上あるものを許可する。
+func matchWildcardSubdomain(pattern, origin string) bool {
+	idx := strings.Index(pattern, "://*.")
+	if idx < 0 {
+		return false
+	}
+	// "https://" 部分と ".example.com" 部分に分ける。
+	scheme := strings.ToLower(pattern[:idx+3])
+	suffix := strings.ToLower(pattern[idx+4:])
+	o := strings.ToLower(origin)
+	if !strings.HasPrefix(o, scheme) || !strings.HasSuffix(o, suffix) {
+		return false
+	}
+	// サブドメイン部分が空（apex そのもの）や不正文字を含む場合は拒否する。
+	host := o[len(scheme) : len(o)-len(suffix)]
+	return host != "" && !strings.ContainsAny(host, "/:@")
+}
@@ -0,0 +1,157 @@
+// 本ファイルは CORS middleware の単体テスト。
+//
+// テスト観点:
+//   - AllowedOrigins 未設定時は Access-Control-* を一切付与しない
+//   - preflight は後段（auth）に到達せず 204 で応答する
+//   - ワイルドカードサブドメインは apex / 別 scheme / 偽装 suffix を許可しない
+//   - credentials 有効時は origin を反射し Allow-Credentials を返す
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// okHandler は呼出有無を記録する terminal handler。
+func okHandler(called *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		*called = true
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func baseCORS() config.CORSConfig {
+	return config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.tenant.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAgeSec:      600,
+	}
+}
+
+func TestCORS_DisabledIsPassThrough(t *testing.T) {
+	called := false
+	h := CORS(config.CORSConfig{})(okHandler(&called))
+	req := httptest.NewRequest(http.MethodGet, "/api/state/get", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !called {
+		t.Fatalf("next should be called when CORS is disabled")
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("no CORS header expected, got %q", got)
+	}
+}
+
+func TestCORS_PreflightShortCircuits(t *testing.T) {
+	called := false
+	h := CORS(baseCORS())(okHandler(&called))
+	req := httptest.NewRequest(http.MethodOptions, "/api/state/get", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if called {
+		t.Fatalf("preflight must not reach next handler")
+	}
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("Allow-Methods = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+		t.Errorf("Allow-Headers = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Max-Age = %q", got)
+	}
+}
+
+func TestCORS_DisallowedPreflightIs403(t *testing.T) {
+	called := false
+	h := CORS(baseCORS())(okHandler(&called))
+	req := httptest.NewRequest(http.MethodOptions, "/api/state/get", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if called || rec.Code != http.StatusForbidden {
+		t.Fatalf("disallowed preflight: called=%v status=%d", called, rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("no Allow-Origin expected, got %q", got)
+	}
+}
+
+func TestCORS_WildcardSubdomain(t *testing.T) {
+	cases := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://acme.tenant.example.com", true},
+		{"https://a.b.tenant.example.com", true},
+		{"https://tenant.example.com", false},
+		{"http://acme.tenant.example.com", false},
+		{"https://acme.tenant.example.com.evil.io", false},
+		{"https://eviltenant.example.com", false},
+	}
+	for _, c := range cases {
+		called := false
+		h := CORS(baseCORS())(okHandler(&called))
+		req := httptest.NewRequest(http.MethodGet, "/api/state/get", nil)
+		req.Header.Set("Origin", c.origin)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if !called {
+			t.Errorf("%s: actual request should always reach next", c.origin)
+		}
+		got := rec.Header().Get("Access-Control-Allow-Origin") == c.origin
+		if got != c.want {
+			t.Errorf("%s: allowed = %v, want %v", c.origin, got, c.want)
+		}
+	}
+}
+
+func TestCORS_StarWithoutCredentials(t *testing.T) {
+	called := false
+	h := CORS(config.CORSConfig{AllowedOrigins: []string{"*"}})(okHandler(&called))
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("Origin", "https://any.example.org")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Allow-Credentials should be absent, got %q", got)
+	}
+}
+
+func TestCORS_CredentialsReflectOrigin(t *testing.T) {
+	cfg := baseCORS()
+	cfg.AllowCredentials = true
+	called := false
+	h := CORS(cfg)(okHandler(&called))
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q", got)
+	}
+	if got := rec.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+}
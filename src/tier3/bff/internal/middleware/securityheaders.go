@@ -0,0 +1,63 @@
+// セキュリティヘッダ middleware。
+//
+// docs 正典:
+//   docs/05_実装/00_ディレクトリ設計/40_tier3レイアウト/04_bff配置.md
+//   docs/03_要件定義/30_非機能要件/E_セキュリティ.md
+//
+// 役割:
+//   config.SecurityHeadersConfig に従い、全応答に CSP / HSTS / X-Content-Type-Options /
+//   Referrer-Policy / X-Frame-Options を付与する。既定値は環境別（dev は緩和、それ以外は厳格）で
+//   config 側が決め、本 middleware は値の付与だけを担う。空値のヘッダは付与しない。
+
+package middleware
+
+// 標準 / 内部 import。
+import (
+	// HTTP server。
+	"net/http"
+	// 数値 → 文字列変換。
+	"strconv"
+
+	// セキュリティヘッダ設定。
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+// SecurityHeaders は cfg に従ってセキュリティヘッダを付与する middleware を返す。
+// cfg.Enabled が false なら pass-through を返す。
+func SecurityHeaders(cfg config.SecurityHeadersConfig) func(http.Handler) http.Handler {
+	// 無効時は next をそのまま返す。
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	// 付与するヘッダは起動時に一度だけ組み立てる。
+	headers := map[string]string{
+		// MIME sniffing は環境に依らず常に禁止する。
+		"X-Content-Type-Options": "nosniff",
+	}
+	if cfg.ContentSecurityPolicy != "" {
+		headers["Content-Security-Policy"] = cfg.ContentSecurityPolicy
+	}
+	if cfg.HSTSMaxAgeSec > 0 {
+		hsts := "max-age=" + strconv.Itoa(cfg.HSTSMaxAgeSec)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+	if cfg.ReferrerPolicy != "" {
+		headers["Referrer-Policy"] = cfg.ReferrerPolicy
+	}
+	if cfg.FrameOptions != "" {
+		headers["X-Frame-Options"] = cfg.FrameOptions
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// handler より前に設定し、エラー応答を含む全応答に付与する。
+			h := w.Header()
+			for k, v := range headers {
+				h.Set(k, v)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// 本ファイルはセキュリティヘッダ middleware の単体テスト。
+//
+// テスト観点:
+//   - 設定値どおりに CSP / HSTS / Referrer-Policy / X-Frame-Options を付与する
+//   - 空値のヘッダは付与しない（nosniff は常に付与）
+//   - 無効時は一切付与しない
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k1s0/k1s0/src/tier3/bff/internal/config"
+)
+
+func TestSecurityHeaders_Strict(t *testing.T) {
+	called := false
+	h := SecurityHeaders(config.SecurityHeadersConfig{
+		Enabled:               true,
+		ContentSecurityPolicy: "default-src 'none'",
+		HSTSMaxAgeSec:         31536000,
+		HSTSIncludeSubdomains: true,
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "DENY",
+	})(okHandler(&called))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if !called {
+		t.Fatalf("next should be called")
+	}
+	want := map[string]string{
+		"Content-Security-Policy":   "default-src 'none'",
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "no-referrer",
+		"X-Frame-Options":           "DENY",
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestSecurityHeaders_EmptyValuesOmitted(t *testing.T) {
+	called := false
+	h := SecurityHeaders(config.SecurityHeadersConfig{Enabled: true})(okHandler(&called))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("nosniff should always be set, got %q", got)
+	}
+	for _, k := range []string{"Content-Security-Policy", "Strict-Transport-Security", "Referrer-Policy", "X-Frame-Options"} {
+		if got := rec.Header().Get(k); got != "" {
+			t.Errorf("%s should be omitted, got %q", k, got)
+		}
+	}
+}
+
+func TestSecurityHeaders_Disabled(t *testing.T) {
+	called := false
+	h := SecurityHeaders(config.SecurityHeadersConfig{Enabled: false, FrameOptions: "DENY"})(okHandler(&called))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/x", nil))
+	if len(rec.Header()) != 0 {
+		t.Errorf("no header expected when disabled, got %v", rec.Header())
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"context"
 	// JSON エンコード / デコード。
 	"encoding/json"
+	// MaxBytesError 判定。
+	"errors"
 	// HTTP server。
 	"net/http"
 	// 時刻（AuditQuery で使う）。
@@ -139,6 +141,12 @@ func writeBadGateway(w http.ResponseWriter, code, message string) {
 func decodeJSON(w http.ResponseWriter, req *http.Request, dst any) bool {
 	// JSON デコードを試みる。
 	if err := json.NewDecoder(req.Body).Decode(dst); err != nil {
+		// BodyLimit middleware の上限超過は 413 として区別する。
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, errorBody{Code: "E-T3-BFF-BODY-TOO-LARGE", Message: "request body too large"})
+			return false
+		}
 		writeBadRequest(w, "E-T3-BFF-INVALID-JSON", "invalid json: "+err.Error())
 		return false
 	}
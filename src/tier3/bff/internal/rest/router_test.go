@@ -86,6 +86,26 @@ func TestStateGet_RejectsInvalidJSON(t *testing.T) {
 	}
 }
 
+func TestStateGet_OversizeBodyReturns413(t *testing.T) {
+	mux := http.NewServeMux()
+	NewRouter(&fakeStateClient{}).Register(mux)
+	// BodyLimit middleware 相当: Content-Length に依らず読込量で打ち切る。
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 16)
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	body := `{"store":"s","key":"` + strings.Repeat("k", 64) + `"}`
+	resp, err := http.Post(srv.URL+"/api/state/get", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversize body should be 413, got %d", resp.StatusCode)
+	}
+}
+
 func TestStateGet_RejectsMissingStoreOrKey(t *testing.T) {
 	srv := newTestServer(t, &fakeStateClient{})
 	defer srv.Close()
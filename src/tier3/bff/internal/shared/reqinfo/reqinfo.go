@@ -0,0 +1,68 @@
+// 要求単位の付帯情報（アクセスログ用）。
+//
+// 役割:
+//   auth middleware / k1s0client は内側で request context を差し替える・受け取るだけなので、
+//   外側の AccessLog からは認証済 subject や実際に呼んだ upstream を読めない。そこで外側が
+//   可変の Info を context に載せ、内側が Set* で書き込む。auth / k1s0client は本 package
+//   だけに依存し、ログ middleware には依存しない。Info を載せていない context では Set* は no-op。
+
+// Package reqinfo は外側の middleware と内側の handler / client が要求単位の付帯情報を受け渡す。
+package reqinfo
+
+// 標準 import。
+import (
+	// 要求 context への格納。
+	"context"
+	// 並行書込の排他。
+	"sync"
+)
+
+// infoKey は Info を context に載せる際のキー型。
+type infoKey struct{}
+
+// Info は内側が書き込む要求単位の付帯情報。GraphQL resolver 等の並行呼出に備えて排他する。
+type Info struct {
+	mu sync.Mutex
+	// 認証済 subject（auth middleware が設定）。
+	subject string
+	// 実際に呼び出した upstream（k1s0client が設定、未呼出なら空）。
+	upstream string
+}
+
+// WithInfo は空の Info を載せた context と、その Info を返す。
+func WithInfo(ctx context.Context) (context.Context, *Info) {
+	info := &Info{}
+	return context.WithValue(ctx, infoKey{}, info), info
+}
+
+// SetSubject は認証済 subject を記録する。
+func SetSubject(ctx context.Context, subject string) {
+	if info, ok := ctx.Value(infoKey{}).(*Info); ok {
+		info.mu.Lock()
+		defer info.mu.Unlock()
+		info.subject = subject
+	}
+}
+
+// SetUpstream は呼び出した upstream の target を記録する。
+func SetUpstream(ctx context.Context, target string) {
+	if info, ok := ctx.Value(infoKey{}).(*Info); ok {
+		info.mu.Lock()
+		defer info.mu.Unlock()
+		info.upstream = target
+	}
+}
+
+// Subject は記録された subject を返す。
+func (i *Info) Subject() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.subject
+}
+
+// Upstream は記録された upstream を返す。
+func (i *Info) Upstream() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.upstream
+}
@@ -0,0 +1,23 @@
+// 要求単位の付帯情報の単体テスト。
+
+package reqinfo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetters_RecordIntoInfo(t *testing.T) {
+	ctx, info := WithInfo(context.Background())
+	SetSubject(ctx, "user-1")
+	SetUpstream(ctx, "tier1:50001")
+	if info.Subject() != "user-1" || info.Upstream() != "tier1:50001" {
+		t.Fatalf("subject=%q upstream=%q", info.Subject(), info.Upstream())
+	}
+}
+
+func TestSetters_NoInfoIsNoop(t *testing.T) {
+	// Info 未設定 context でも panic しない。
+	SetSubject(context.Background(), "user-1")
+	SetUpstream(context.Background(), "tier1:50001")
+}